@@ -0,0 +1,89 @@
+package admission
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+	"math/rand"
+)
+
+var (
+	_ cache.Admission[int]      = (*TinyLFU[int])(nil)
+	_ cache.AccessRecorder[int] = (*TinyLFU[int])(nil)
+	_ cache.Admission[int]      = (*SecondSighting[int])(nil)
+	_ cache.Admission[int]      = (*Probabilistic[int])(nil)
+)
+
+// TinyLFU admits a candidate only if a frequency sketch of every access
+// estimates it more popular than the victim. It is not safe for concurrent
+// use, like the caches it is installed in.
+type TinyLFU[K comparable] struct {
+	sketch *internal.TinyLFU
+}
+
+// NewTinyLFU creates a TinyLFU admission sized for a cache of the given size.
+func NewTinyLFU[K comparable](size int) (*TinyLFU[K], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &TinyLFU[K]{sketch: internal.NewTinyLFU(size)}, nil
+}
+
+// Record counts an access to key.
+func (a *TinyLFU[K]) Record(key K) {
+	a.sketch.Increment(internal.Hash(key))
+}
+
+// Admit returns true if candidate was accessed more often than victim.
+func (a *TinyLFU[K]) Admit(candidate, victim K) bool {
+	return a.sketch.Estimate(internal.Hash(candidate)) > a.sketch.Estimate(internal.Hash(victim))
+}
+
+// SecondSighting admits a candidate the second time it is offered, a bloom
+// filter remembers the candidates seen once and is cleared after a number
+// of sightings proportional to the cache size, so one-hit wonders never
+// enter the cache.
+type SecondSighting[K comparable] struct {
+	door      *internal.Doorkeeper
+	sightings int
+	resetAt   int
+}
+
+// NewSecondSighting creates a SecondSighting admission sized for a cache of
+// the given size.
+func NewSecondSighting[K comparable](size int) (*SecondSighting[K], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	return &SecondSighting[K]{door: internal.NewDoorkeeper(size), resetAt: 10 * size}, nil
+}
+
+// Admit returns true if candidate was offered before.
+func (a *SecondSighting[K]) Admit(candidate, _ K) bool {
+	if a.sightings++; a.sightings >= a.resetAt {
+		a.door.Reset()
+		a.sightings = 0
+	}
+	return a.door.Allow(internal.Hash(candidate))
+}
+
+// Probabilistic admits a candidate with a fixed probability, so a scan
+// only replaces that share of the cache.
+type Probabilistic[K comparable] struct {
+	p   float64
+	rnd *rand.Rand
+}
+
+// NewProbabilistic creates a Probabilistic admission admitting with
+// probability p, seed seeds its random source.
+func NewProbabilistic[K comparable](p float64, seed int64) (*Probabilistic[K], error) {
+	if p < 0.0 || p > 1.0 {
+		return nil, errors.New("invalid probability")
+	}
+	return &Probabilistic[K]{p: p, rnd: rand.New(rand.NewSource(seed))}, nil
+}
+
+// Admit returns true with probability p.
+func (a *Probabilistic[K]) Admit(_, _ K) bool {
+	return a.rnd.Float64() < a.p
+}
@@ -0,0 +1,87 @@
+package admission
+
+import (
+	"fast-cache/lru"
+	"testing"
+)
+
+func TestTinyLFU(t *testing.T) {
+	a, err := NewTinyLFU[int](16)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		a.Record(1)
+	}
+	a.Record(2)
+	if !a.Admit(1, 2) || a.Admit(2, 1) || a.Admit(3, 2) {
+		t.Fatalf("bad admission")
+	}
+}
+
+func TestSecondSighting(t *testing.T) {
+	a, err := NewSecondSighting[int](16)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if a.Admit(1, 0) || !a.Admit(1, 0) {
+		t.Fatalf("1 should be admitted on second sighting")
+	}
+	// the filter is cleared after 10 sightings per entry
+	for i := 0; i < 160; i++ {
+		a.Admit(i+100, 0)
+	}
+	if a.Admit(1, 0) {
+		t.Fatalf("1 should be forgotten")
+	}
+}
+
+func TestProbabilistic(t *testing.T) {
+	a, err := NewProbabilistic[int](0.25, 1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	admitted := 0
+	for i := 0; i < 10000; i++ {
+		if a.Admit(i, 0) {
+			admitted++
+		}
+	}
+	if admitted < 2200 || admitted > 2800 {
+		t.Fatalf("admitted %d of 10000", admitted)
+	}
+	if _, err := NewProbabilistic[int](1.5, 1); err == nil {
+		t.Fatalf("invalid probability should fail")
+	}
+}
+
+func TestScanResistance(t *testing.T) {
+	l, err := lru.New[int, int](100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a, err := NewTinyLFU[int](100)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.SetAdmission(a)
+
+	// a hot set referenced repeatedly, then a scan of one-hit wonders
+	for r := 0; r < 5; r++ {
+		for i := 0; i < 100; i++ {
+			if _, ok := l.Get(i); !ok {
+				l.Add(i, i)
+			}
+		}
+	}
+	for i := 1000; i < 1300; i++ {
+		if _, ok := l.Get(i); !ok {
+			l.Add(i, i)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if !l.Contains(i) {
+			t.Fatalf("hot key %d flushed by the scan", i)
+		}
+	}
+}
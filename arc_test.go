@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fast-cache/lru"
+	"testing"
+)
+
+func BenchmarkARC_Rand(b *testing.B) {
+	l, err := lru.NewARC[int64, int64](8192)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	trace := make([]int64, b.N*2)
+	for i := 0; i < b.N*2; i++ {
+		trace[i] = getRand(b) % 32768
+	}
+
+	b.ResetTimer()
+
+	var hit, miss int
+	for i := 0; i < 2*b.N; i++ {
+		if i%2 == 0 {
+			l.Add(trace[i], trace[i])
+		} else {
+			if _, ok := l.Get(trace[i]); ok {
+				hit++
+			} else {
+				miss++
+			}
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+}
+
+func TestARC_RandomOps(t *testing.T) {
+	size := 128
+	l, err := lru.NewARC[int64, int64](128)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	n := 200000
+	for i := 0; i < n; i++ {
+		key := getRand(t) % 512
+		r := getRand(t)
+		switch r % 3 {
+		case 0:
+			l.Add(key, key)
+		case 1:
+			l.Get(key)
+		case 2:
+			l.Remove(key)
+		}
+
+		if l.Len() > size {
+			t.Fatalf("bad: t1+t2: %d", l.Len())
+		}
+	}
+}
+
+// Test that a hit in the B1 ghost list grows T1 at the expense of T2
+func TestARC_Adaptive(t *testing.T) {
+	l, err := lru.NewARC[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Fill t1
+	for i := 0; i < 4; i++ {
+		l.Add(i, i)
+	}
+
+	// Move 0 and 1 to t2
+	l.Get(0)
+	l.Get(1)
+
+	// Evict 2 from t1 into b1
+	l.Add(4, 4)
+	if l.Contains(2) {
+		t.Fatalf("2 should have been evicted")
+	}
+
+	// current state
+	// t1 : (MRU) [4, 3] (LRU)
+	// t2 : (MRU) [1, 0] (LRU)
+	// b1 : (MRU) [2] (LRU)
+	// b2 : (MRU) [] (LRU)
+
+	// Ghost hit on 2 raises p to 1, so t1 gives up 3 and 2 lands in t2
+	l.Add(2, 2)
+	if l.Contains(3) {
+		t.Fatalf("3 should have been evicted")
+	}
+	if keys := l.Keys(false); len(keys) != 4 || keys[2] != 2 || keys[3] != 4 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	// A miss now evicts from t2 since t1 is at target
+	l.Add(5, 5)
+	if l.Contains(0) {
+		t.Fatalf("0 should have been evicted")
+	}
+}
+
+// Test that Peek doesn't update recent-ness
+func TestARC_Peek(t *testing.T) {
+	l, err := lru.NewARC[int, int](2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.Add(2, 2)
+	if v, ok := l.Peek(1); !ok || v != 1 {
+		t.Errorf("1 should be set to 1: %v, %v", v, ok)
+	}
+
+	l.Add(3, 3)
+	if l.Contains(1) {
+		t.Errorf("should not have updated recent-ness of 1")
+	}
+}
@@ -0,0 +1,310 @@
+// Package belady implements Bélády's optimal offline replacement policy. It
+// knows the whole trace up front, which no real cache does, so its hit
+// ratio is an upper bound to compare the other policies against.
+package belady
+
+import (
+	"container/heap"
+	"errors"
+	"fast-cache/cache"
+	"math"
+	"sort"
+)
+
+// EvictCallback is used to get a callback when a cache entry is evicted
+type EvictCallback[K comparable, V any] func(key K, value V)
+
+var _ cache.Cache[int, int] = (*Belady[int, int])(nil)
+var _ cache.StatsProvider = (*Belady[int, int])(nil)
+var _ cache.CapacityProvider = (*Belady[int, int])(nil)
+
+// never is the next use of a key that is not accessed again.
+const never = math.MaxInt
+
+// entry is a cached value with the position of its next use in the trace.
+type entry[K comparable, V any] struct {
+	index int
+	Key   K
+	Val   V
+	next  int
+	seq   int // order of the last access, breaks ties between next uses
+}
+
+// Belady implements a non-thread safe fixed size cache that evicts the key
+// whose next use in the trace is farthest in the future, keys never used
+// again first and the least recently used among them. A new key used later
+// than every cached one is not cached at all, so no online policy, with or
+// without admission, can hit more often.
+//
+// The trace is the sequence of keys of the accesses the cache will see.
+// Every Get consumes the next access of the trace if its key matches, and
+// so does an Add, except the Add of the key of a Get that just missed, which
+// completes the same access. A key not in the trace, or accessed out of
+// order, is never used again as far as the cache can tell.
+type Belady[K comparable, V any] struct {
+	size  int
+	trace []K
+	uses  map[K][]int // positions of the accesses of every key in the trace
+	pos   int         // position of the next access in the trace
+	seq   int
+
+	// missed is set while the key of the last Get, which missed, may still
+	// be added as part of the same access.
+	missed  bool
+	missKey K
+
+	items   map[K]*entry[K, V]
+	queue   queue[K, V]
+	onEvict EvictCallback[K, V]
+	stats   *cache.StatsCounter
+}
+
+// New constructs a Belady of the given size for the given trace.
+func New[K comparable, V any](size int, trace []K, onEvict EvictCallback[K, V]) (*Belady[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+
+	c := &Belady[K, V]{
+		size:    size,
+		trace:   trace,
+		uses:    make(map[K][]int),
+		items:   make(map[K]*entry[K, V], size),
+		queue:   make(queue[K, V], 0, size),
+		onEvict: onEvict,
+	}
+	for i, k := range trace {
+		c.uses[k] = append(c.uses[k], i)
+	}
+	return c, nil
+}
+
+// access consumes the next access of the trace if it is of key.
+func (c *Belady[K, V]) access(key K) {
+	if c.pos < len(c.trace) && c.trace[c.pos] == key {
+		c.pos++
+	}
+	c.seq++
+}
+
+// nextUse returns the position of the next access of key, never if there
+// is none.
+func (c *Belady[K, V]) nextUse(key K) int {
+	uses := c.uses[key]
+	if i := sort.SearchInts(uses, c.pos); i < len(uses) {
+		return uses[i]
+	}
+	return never
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *Belady[K, V]) Add(key K, value V) (evicted bool) {
+	if !c.missed || c.missKey != key {
+		c.access(key)
+	}
+	c.missed = false
+
+	// Check for existing item
+	if e, ok := c.items[key]; ok {
+		e.Val = value
+		c.reference(e)
+		c.stats.Updated()
+		return false
+	}
+
+	next := c.nextUse(key)
+	if len(c.items) >= c.size {
+		if next > c.queue[0].next {
+			return false
+		}
+		c.removeFarthest()
+		evicted = true
+	}
+	e := &entry[K, V]{Key: key, Val: value, next: next, seq: c.seq}
+	heap.Push(&c.queue, e)
+	c.items[key] = e
+	c.stats.Added()
+	return evicted
+}
+
+// reference updates the next use of e after an access.
+func (c *Belady[K, V]) reference(e *entry[K, V]) {
+	e.next, e.seq = c.nextUse(e.Key), c.seq
+	heap.Fix(&c.queue, e.index)
+}
+
+// removeFarthest evicts the entry whose next use is farthest.
+func (c *Belady[K, V]) removeFarthest() {
+	e := heap.Pop(&c.queue).(*entry[K, V])
+	delete(c.items, e.Key)
+	c.stats.Evicted(cache.EvictReasonCapacity, 1)
+	if c.onEvict != nil {
+		c.onEvict(e.Key, e.Val)
+	}
+}
+
+// Get looks up a key's value from the cache.
+func (c *Belady[K, V]) Get(key K) (value V, ok bool) {
+	c.access(key)
+	if e, ok := c.items[key]; ok {
+		c.missed = false
+		c.reference(e)
+		c.stats.Hit()
+		return e.Val, true
+	}
+	c.missed, c.missKey = true, key
+	c.stats.Miss()
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without consuming
+// an access of the trace.
+func (c *Belady[K, V]) Peek(key K) (value V, ok bool) {
+	if e, ok := c.items[key]; ok {
+		return e.Val, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without consuming an access of
+// the trace.
+func (c *Belady[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Belady[K, V]) Remove(key K) (present bool) {
+	if e, ok := c.items[key]; ok {
+		heap.Remove(&c.queue, e.index)
+		delete(c.items, key)
+		c.stats.Evicted(cache.EvictReasonRemoved, 1)
+		if c.onEvict != nil {
+			c.onEvict(e.Key, e.Val)
+		}
+		return true
+	}
+	return false
+}
+
+// entries returns the entries in eviction order, the farthest next use
+// first.
+func (c *Belady[K, V]) entries(reverse bool) []*entry[K, V] {
+	entries := make(queue[K, V], len(c.queue))
+	copy(entries, c.queue)
+	sort.Slice(entries, func(i, j int) bool {
+		if reverse {
+			return entries.Less(j, i)
+		}
+		return entries.Less(i, j)
+	})
+	return entries
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be evicted
+// to the last.
+func (c *Belady[K, V]) Keys(reverse bool) []K {
+	entries := c.entries(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, in the same order as
+// Keys.
+func (c *Belady[K, V]) Values(reverse bool) []V {
+	entries := c.entries(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Val
+	}
+	return values
+}
+
+// Len returns the number of items in the cache.
+func (c *Belady[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Cap returns the size of the cache.
+func (c *Belady[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache. The position in the trace
+// is kept.
+func (c *Belady[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, e := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, e.Val)
+		}
+		delete(c.items, k)
+	}
+	c.queue = c.queue[:0]
+}
+
+// Resize changes the cache size.
+func (c *Belady[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	for len(c.items) > size {
+		c.removeFarthest()
+		evicted++
+	}
+	c.size = size
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *Belady[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Belady[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// queue is a max-heap of entries on their next use.
+type queue[K comparable, V any] []*entry[K, V]
+
+var _ heap.Interface = (*queue[struct{}, interface{}])(nil)
+
+func (q queue[K, V]) Len() int { return len(q) }
+
+func (q queue[K, V]) Less(i, j int) bool {
+	if q[i].next != q[j].next {
+		return q[i].next > q[j].next
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q queue[K, V]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *queue[K, V]) Push(x interface{}) {
+	e := x.(*entry[K, V])
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *queue[K, V]) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil // avoid memory leak
+	e.index = -1
+	*q = old[:n-1]
+	return e
+}
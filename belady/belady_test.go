@@ -0,0 +1,171 @@
+package belady
+
+import (
+	"fast-cache/clock"
+	"fast-cache/fifo"
+	"fast-cache/lru"
+	"fast-cache/workload"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// optimal returns the most hits a cache of the given size can have on
+// trace, trying every choice of eviction and bypass.
+func optimal(trace []int, size int) int {
+	type state struct{ pos, cached int }
+	memo := make(map[state]int)
+	var best func(pos, cached int) int
+	best = func(pos, cached int) int {
+		if pos == len(trace) {
+			return 0
+		}
+		s := state{pos, cached}
+		if h, ok := memo[s]; ok {
+			return h
+		}
+		bit := 1 << trace[pos]
+		var h int
+		if cached&bit != 0 {
+			h = 1 + best(pos+1, cached)
+		} else {
+			h = best(pos+1, cached)
+			n := 0
+			for c := cached; c != 0; c &= c - 1 {
+				n++
+			}
+			if n < size {
+				h = max(h, best(pos+1, cached|bit))
+			} else {
+				for victim := cached; victim != 0; victim &= victim - 1 {
+					low := victim & -victim
+					h = max(h, best(pos+1, cached&^low|bit))
+				}
+			}
+		}
+		memo[s] = h
+		return h
+	}
+	return best(0, 0)
+}
+
+func replay(c *Belady[int, int], trace []int) (hits int) {
+	for _, k := range trace {
+		if _, ok := c.Get(k); ok {
+			hits++
+			continue
+		}
+		c.Add(k, k)
+	}
+	return hits
+}
+
+func TestBelady_Optimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		trace := make([]int, 16)
+		for j := range trace {
+			trace[j] = r.Intn(6)
+		}
+		size := 1 + r.Intn(4)
+		c, err := New[int, int](size, trace, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if got, want := replay(c, trace), optimal(trace, size); got != want {
+			t.Fatalf("trace %v size %d: %d hits, want %d", trace, size, got, want)
+		}
+	}
+}
+
+func TestBelady_UpperBound(t *testing.T) {
+	for _, spec := range workload.Suite(1, 1<<10) {
+		keys := workload.Keys(spec.New(), 1<<14)
+		opt, err := New[uint64, uint64](64, keys, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		l, _ := lru.New[uint64, uint64](64)
+		a, _ := lru.NewARC[uint64, uint64](64)
+		s, _ := fifo.NewS3FIFO[uint64, uint64](64, nil)
+		p, _ := clock.NewClockPro[uint64, uint64](64, nil)
+		bound := workload.Replay[uint64](opt, keys)
+		for name, hits := range map[string]int{
+			"lru":       workload.Replay[uint64](l, keys),
+			"arc":       workload.Replay[uint64](a, keys),
+			"s3fifo":    workload.Replay[uint64](s, keys),
+			"clock-pro": workload.Replay[uint64](p, keys),
+		} {
+			if hits > bound {
+				t.Fatalf("%s: %s has %d hits, more than optimal %d", spec.Name, name, hits, bound)
+			}
+		}
+	}
+}
+
+func TestBelady_Access(t *testing.T) {
+	// the Add after a missed Get completes the access, so the repeated key
+	// is a hit, and 2, never used again, is not cached
+	trace := []int{1, 1, 2, 1}
+	c, err := New[int, int](1, trace, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if hits := replay(c, trace); hits != 2 {
+		t.Fatalf("bad: %d hits", hits)
+	}
+	if c.Contains(2) || !c.Contains(1) {
+		t.Fatalf("bad: %v", c.Keys(false))
+	}
+}
+
+func TestBelady_Evict(t *testing.T) {
+	var evicted []int
+	onEvict := func(k, v int) {
+		evicted = append(evicted, k)
+	}
+	trace := []int{1, 2, 3, 4, 4, 2, 1, 3}
+	c, err := New[int, int](3, trace, onEvict)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, k := range trace[:3] {
+		c.Add(k, k)
+	}
+	// 3 is used last, then 1, then 2
+	if keys := c.Keys(false); !reflect.DeepEqual(keys, []int{3, 1, 2}) {
+		t.Fatalf("bad: %v", keys)
+	}
+	if keys := c.Keys(true); !reflect.DeepEqual(keys, []int{2, 1, 3}) {
+		t.Fatalf("bad: %v", keys)
+	}
+	// 4 is used before 3 again
+	if !c.Add(4, 4) || !reflect.DeepEqual(evicted, []int{3}) {
+		t.Fatalf("bad: %v", evicted)
+	}
+	if _, err := c.Resize(1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// 4 is used next, then 2 and 1
+	if keys := c.Keys(false); !reflect.DeepEqual(keys, []int{4}) || !reflect.DeepEqual(evicted, []int{3, 1, 2}) {
+		t.Fatalf("bad: %v %v", keys, evicted)
+	}
+}
+
+func TestBelady_NoTrace(t *testing.T) {
+	// without a trace no key is used again, the least recently used goes
+	c, err := New[int, int](2, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Get(1)
+	c.Add(3, 3)
+	if !c.Contains(1) || c.Contains(2) || !c.Contains(3) {
+		t.Fatalf("bad: %v", c.Keys(false))
+	}
+	if _, err := New[int, int](0, nil, nil); err == nil {
+		t.Fatalf("should fail on a zero size")
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+// Admission decides, separately from the eviction policy, if a new key may
+// enter a full cache in place of the victim the policy would evict.
+type Admission[K comparable] interface {
+	// Admit returns true if candidate should replace victim.
+	Admit(candidate, victim K) bool
+}
+
+// AccessRecorder is implemented by Admission policies that need to see
+// every access, e.g. to estimate key frequencies. Caches call Record on
+// every Get and Add.
+type AccessRecorder[K comparable] interface {
+	Record(key K)
+}
+
+// Admitter holds the Admission of a cache. The zero value admits every key,
+// so policies embed it and only pay for admission once it is set.
+type Admitter[K comparable] struct {
+	admission Admission[K]
+	recorder  AccessRecorder[K]
+}
+
+// Set installs admission, nil removes the admission policy.
+func (a *Admitter[K]) Set(admission Admission[K]) {
+	a.admission = admission
+	a.recorder, _ = admission.(AccessRecorder[K])
+}
+
+// Enabled returns if an admission policy is set.
+func (a *Admitter[K]) Enabled() bool {
+	return a.admission != nil
+}
+
+// Record passes an access to key to the admission policy if it records
+// accesses.
+func (a *Admitter[K]) Record(key K) {
+	if a.recorder != nil {
+		a.recorder.Record(key)
+	}
+}
+
+// Admit returns true if candidate may replace victim, always true without
+// an admission policy.
+func (a *Admitter[K]) Admit(candidate, victim K) bool {
+	return a.admission == nil || a.admission.Admit(candidate, victim)
+}
@@ -0,0 +1,48 @@
+package cache_test
+
+import (
+	"fast-cache/cache"
+	"testing"
+)
+
+// rejectAll never admits a candidate and counts recorded accesses.
+type rejectAll struct {
+	records int
+}
+
+func (a *rejectAll) Admit(_, _ int) bool { return false }
+
+func (a *rejectAll) Record(int) { a.records++ }
+
+func TestAdmission(t *testing.T) {
+	for name, c := range policies(t, 4) {
+		as, ok := c.(interface{ SetAdmission(cache.Admission[int]) })
+		if !ok {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			a := new(rejectAll)
+			as.SetAdmission(a)
+			for i := 0; i < 4; i++ {
+				c.Add(i, i)
+			}
+			c.Get(0)
+			if c.Add(4, 4) || c.Contains(4) || c.Len() != 4 {
+				t.Fatalf("4 should be rejected: %v", c.Keys(false))
+			}
+			// updates never need admission
+			c.Add(1, 10)
+			if v, _ := c.Peek(1); v != 10 {
+				t.Fatalf("Peek(1) = %d", v)
+			}
+			if a.records != 7 {
+				t.Fatalf("records = %d", a.records)
+			}
+
+			as.SetAdmission(nil)
+			if !c.Add(4, 4) || !c.Contains(4) || c.Len() != 4 {
+				t.Fatalf("4 should be admitted: %v", c.Keys(false))
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+// Cache is the policy-agnostic interface implemented by every eviction
+// policy in this module (LRU, 2Q, LRU-K, LFU, FIFO and the clock family),
+// so callers can swap policies without rewriting call sites.
+type Cache[K comparable, V any] interface {
+	// Add adds a value to the cache, returns true if an eviction occurred.
+	Add(key K, value V) (evicted bool)
+
+	// Get returns key's value from the cache and updates the policy
+	// bookkeeping (recency, frequency, reference bits) of the key.
+	Get(key K) (value V, ok bool)
+
+	// Contains checks if a key exists in cache without updating the policy
+	// bookkeeping of the key.
+	Contains(key K) (ok bool)
+
+	// Peek returns key's value without updating the policy bookkeeping of
+	// the key.
+	Peek(key K) (value V, ok bool)
+
+	// Remove removes a key from the cache, returning if the key was contained.
+	Remove(key K) (present bool)
+
+	// Keys returns a slice of the keys in the cache in policy order,
+	// reverse flips that order.
+	Keys(reverse bool) []K
+
+	// Values returns a slice of the values in the cache in the same order
+	// as Keys.
+	Values(reverse bool) []V
+
+	// Len returns the number of items in the cache.
+	Len() int
+
+	// Purge clears all cache entries.
+	Purge()
+
+	// Resize resizes cache, returning number evicted.
+	Resize(size int) (evicted int, err error)
+}
+
+// CapacityProvider is implemented by caches that report their capacity,
+// the size they were constructed with or last resized to.
+type CapacityProvider interface {
+	// Cap returns the number of entries the cache holds at most, or its
+	// max cost for caches bounded by cost.
+	Cap() int
+}
+
+// EvictReason describes why an entry left the cache.
+type EvictReason uint8
+
+const (
+	// EvictReasonCapacity means the entry was evicted to make room.
+	EvictReasonCapacity EvictReason = iota
+	// EvictReasonExpired means the entry outlived its TTL.
+	EvictReasonExpired
+	// EvictReasonRemoved means the entry was removed explicitly.
+	EvictReasonRemoved
+	// EvictReasonPurged means the entry was dropped by Purge.
+	EvictReasonPurged
+
+	numEvictReasons
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictReasonCapacity:
+		return "capacity"
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonRemoved:
+		return "removed"
+	case EvictReasonPurged:
+		return "purged"
+	}
+	return "unknown"
+}
+
+// Coster returns the cost of an entry, e.g. its size in bytes, for caches
+// bounded by total cost instead of entry count. An entry with a negative
+// cost, or costing more than the cache's max cost, is not cached and drops
+// the previous value of its key.
+type Coster[K comparable, V any] func(key K, value V) int64
@@ -0,0 +1,119 @@
+package cache_test
+
+import (
+	"fast-cache/belady"
+	"fast-cache/cache"
+	"fast-cache/clock"
+	"fast-cache/fifo"
+	"fast-cache/lfu"
+	"fast-cache/lru"
+	"fast-cache/tinylfu"
+	"testing"
+)
+
+func policies(t *testing.T, size int) map[string]cache.Cache[int, int] {
+	t.Helper()
+	c := make(map[string]cache.Cache[int, int])
+	must := func(name string, cc cache.Cache[int, int], err error) {
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		c[name] = cc
+	}
+	l, err := lru.New[int, int](size)
+	must("lru", l, err)
+	q, err := lru.New2Q[int, int](size)
+	must("2q", q, err)
+	sl, err := lru.NewSLRU[int, int](size, lru.DefaultSLRUProtectedRatio)
+	must("slru", sl, err)
+	k, err := lru.NewLruK[int, int](size, 2)
+	must("lru-k", k, err)
+	lf, err := lfu.NewLFU[int, int](size, nil)
+	must("lfu", lf, err)
+	bl, err := lfu.NewBucketLFU[int, int](size, nil)
+	must("lfu-bucket", bl, err)
+	tl, err := tinylfu.New[int, int](size, nil)
+	must("tinylfu", tl, err)
+	f, err := fifo.NewFIFO[int, int](size, nil)
+	must("fifo", f, err)
+	s3, err := fifo.NewS3FIFO[int, int](size, nil)
+	must("s3fifo", s3, err)
+	sv, err := fifo.NewSieve[int, int](size, nil)
+	must("sieve", sv, err)
+	ck, err := clock.NewClock[int, int](size, nil)
+	must("clock", ck, err)
+	cs, err := clock.NewClockSweep[int, int](size, nil)
+	must("clock-sweep", cs, err)
+	ws, err := clock.NewWSClock[int, int](size, nil)
+	must("wsclock", ws, err)
+	cp, err := clock.NewClockPro[int, int](size, nil)
+	must("clock-pro", cp, err)
+	bd, err := belady.New[int, int](size, nil, nil)
+	must("belady", bd, err)
+	return c
+}
+
+func TestCacheInterface(t *testing.T) {
+	for name, c := range policies(t, 4) {
+		t.Run(name, func(t *testing.T) {
+			c.Add(1, 1)
+			c.Add(2, 2)
+			if v, ok := c.Get(1); !ok || v != 1 {
+				t.Fatalf("Get(1) = %v, %v", v, ok)
+			}
+			if v, ok := c.Peek(2); !ok || v != 2 {
+				t.Fatalf("Peek(2) = %v, %v", v, ok)
+			}
+			if !c.Contains(2) || c.Contains(3) {
+				t.Fatalf("bad Contains")
+			}
+			if got := c.Len(); got != 2 {
+				t.Fatalf("Len() = %d", got)
+			}
+			if got := len(c.Keys(false)); got != 2 {
+				t.Fatalf("len(Keys) = %d", got)
+			}
+			if got := len(c.Values(true)); got != 2 {
+				t.Fatalf("len(Values) = %d", got)
+			}
+			if !c.Remove(2) || c.Remove(2) {
+				t.Fatalf("bad Remove")
+			}
+			if _, err := c.Resize(0); err == nil {
+				t.Fatalf("Resize(0) should fail")
+			}
+			if _, err := c.Resize(8); err != nil {
+				t.Fatalf("Resize(8): %v", err)
+			}
+			c.Purge()
+			if got := c.Len(); got != 0 {
+				t.Fatalf("Len() after Purge = %d", got)
+			}
+		})
+	}
+}
+
+func TestCacheInterface_Resize(t *testing.T) {
+	for name, c := range policies(t, 8) {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 8; i++ {
+				c.Add(i, i)
+			}
+			if _, err := c.Resize(4); err != nil {
+				t.Fatalf("Resize(4): %v", err)
+			}
+			if got := c.Len(); got > 4 {
+				t.Fatalf("Len() after Resize = %d", got)
+			}
+			if got := c.(cache.CapacityProvider).Cap(); got != 4 {
+				t.Fatalf("Cap() after Resize = %d, want 4", got)
+			}
+			for i := 8; i < 16; i++ {
+				c.Add(i, i)
+				if got := c.Len(); got > 4 {
+					t.Fatalf("Len() = %d, want <= 4", got)
+				}
+			}
+		})
+	}
+}
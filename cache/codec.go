@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+)
+
+var (
+	_ Codec[int, int] = GobCodec[int, int]{}
+	_ Codec[int, int] = JSONCodec[int, int]{}
+	_ Codec[int, int] = BinaryCodec[int, int]{}
+)
+
+// Codec encodes the entries of a snapshot. Its name is recorded in the
+// snapshot header, and a snapshot only restores with a codec of the same
+// name.
+type Codec[K comparable, V any] interface {
+	// Name identifies the encoding.
+	Name() string
+
+	// NewEncoder returns an encoder writing entries to w.
+	NewEncoder(w io.Writer) EntryEncoder[K, V]
+
+	// NewDecoder returns a decoder reading the entries written by an
+	// encoder of the same codec from r.
+	NewDecoder(r io.Reader) EntryDecoder[K, V]
+}
+
+// EntryEncoder writes snapshot entries.
+type EntryEncoder[K comparable, V any] interface {
+	Encode(e *SnapshotEntry[K, V]) error
+}
+
+// EntryDecoder reads snapshot entries, e is zero on every call.
+type EntryDecoder[K comparable, V any] interface {
+	Decode(e *SnapshotEntry[K, V]) error
+}
+
+// GobCodec encodes entries with encoding/gob, keys and values must be gob
+// encodable. It is the default codec of the caches.
+type GobCodec[K comparable, V any] struct{}
+
+func (GobCodec[K, V]) Name() string { return "gob" }
+
+func (GobCodec[K, V]) NewEncoder(w io.Writer) EntryEncoder[K, V] {
+	return gobEncoder[K, V]{gob.NewEncoder(w)}
+}
+
+func (GobCodec[K, V]) NewDecoder(r io.Reader) EntryDecoder[K, V] {
+	return gobDecoder[K, V]{gob.NewDecoder(r)}
+}
+
+type gobEncoder[K comparable, V any] struct{ enc *gob.Encoder }
+
+func (e gobEncoder[K, V]) Encode(entry *SnapshotEntry[K, V]) error { return e.enc.Encode(entry) }
+
+type gobDecoder[K comparable, V any] struct{ dec *gob.Decoder }
+
+func (d gobDecoder[K, V]) Decode(entry *SnapshotEntry[K, V]) error { return d.dec.Decode(entry) }
+
+// JSONCodec encodes entries as JSON lines with encoding/json, keys and
+// values must survive a JSON round trip.
+type JSONCodec[K comparable, V any] struct{}
+
+func (JSONCodec[K, V]) Name() string { return "json" }
+
+func (JSONCodec[K, V]) NewEncoder(w io.Writer) EntryEncoder[K, V] {
+	return jsonEncoder[K, V]{json.NewEncoder(w)}
+}
+
+func (JSONCodec[K, V]) NewDecoder(r io.Reader) EntryDecoder[K, V] {
+	return jsonDecoder[K, V]{json.NewDecoder(r)}
+}
+
+type jsonEncoder[K comparable, V any] struct{ enc *json.Encoder }
+
+func (e jsonEncoder[K, V]) Encode(entry *SnapshotEntry[K, V]) error { return e.enc.Encode(entry) }
+
+type jsonDecoder[K comparable, V any] struct{ dec *json.Decoder }
+
+func (d jsonDecoder[K, V]) Decode(entry *SnapshotEntry[K, V]) error { return d.dec.Decode(entry) }
+
+// BinaryCodec encodes entries as raw little endian binary with
+// encoding/binary, the most compact and fastest codec. Keys and values
+// must have a fixed size: numbers, bools, and arrays or structs of them;
+// int and uint are stored as 64-bit.
+type BinaryCodec[K comparable, V any] struct{}
+
+// NewBinaryCodec returns a BinaryCodec, failing if K or V does not have a
+// fixed size.
+func NewBinaryCodec[K comparable, V any]() (BinaryCodec[K, V], error) {
+	var (
+		k K
+		v V
+	)
+	if fixedSize(&k) < 0 || fixedSize(&v) < 0 {
+		return BinaryCodec[K, V]{}, errors.New("must use fixed size keys and values")
+	}
+	return BinaryCodec[K, V]{}, nil
+}
+
+// fixedSize returns the encoded size of *p, -1 if it is not fixed.
+func fixedSize(p any) int {
+	switch p.(type) {
+	case *int, *uint:
+		return 8
+	}
+	if reflect.TypeOf(p).Elem().Kind() == reflect.Slice {
+		// binary sizes a slice by its length
+		return -1
+	}
+	return binary.Size(p)
+}
+
+func (BinaryCodec[K, V]) Name() string { return "binary" }
+
+func (BinaryCodec[K, V]) NewEncoder(w io.Writer) EntryEncoder[K, V] {
+	return binaryCodec[K, V]{w: w}
+}
+
+func (BinaryCodec[K, V]) NewDecoder(r io.Reader) EntryDecoder[K, V] {
+	return binaryCodec[K, V]{r: r}
+}
+
+// binaryCodec encodes to w or decodes from r.
+type binaryCodec[K comparable, V any] struct {
+	w io.Writer
+	r io.Reader
+}
+
+func (c binaryCodec[K, V]) Encode(e *SnapshotEntry[K, V]) error {
+	var referencedAt int64
+	if !e.ReferencedAt.IsZero() {
+		referencedAt = e.ReferencedAt.UnixNano()
+	}
+	for _, p := range []any{&e.Key, &e.Value, e.Segment, int64(e.Count), int64(e.Age), referencedAt} {
+		switch v := p.(type) {
+		case *int:
+			p = int64(*v)
+		case *uint:
+			p = uint64(*v)
+		}
+		if err := binary.Write(c.w, binary.LittleEndian, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c binaryCodec[K, V]) Decode(e *SnapshotEntry[K, V]) error {
+	var count, age, referencedAt int64
+	for _, p := range []any{&e.Key, &e.Value, &e.Segment, &count, &age, &referencedAt} {
+		var err error
+		switch v := p.(type) {
+		case *int:
+			var n int64
+			err = binary.Read(c.r, binary.LittleEndian, &n)
+			*v = int(n)
+		case *uint:
+			var n uint64
+			err = binary.Read(c.r, binary.LittleEndian, &n)
+			*v = uint(n)
+		default:
+			err = binary.Read(c.r, binary.LittleEndian, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	e.Count, e.Age = int(count), int(age)
+	if referencedAt != 0 {
+		e.ReferencedAt = time.Unix(0, referencedAt)
+	}
+	return nil
+}
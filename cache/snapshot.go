@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// Snapshotter is implemented by caches that can save their contents and
+// policy state to a stream and restore them, e.g. across restarts.
+type Snapshotter interface {
+	// Snapshot writes the entries and policy state of the cache to w.
+	Snapshot(w io.Writer) error
+
+	// Restore replaces the contents of the cache with a snapshot read from
+	// r. The cache is left untouched if the snapshot is invalid.
+	Restore(r io.Reader) error
+}
+
+// SnapshotEntry is an entry of a snapshot with the policy state it needs.
+type SnapshotEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+
+	// Segment is the list the entry is in for caches made of several, e.g.
+	// the recent, frequent and ghost lists of TwoQueueCache.
+	Segment uint8
+
+	// Count, Age and ReferencedAt are the reference count, the cache age at
+	// the last reference and the time of that reference of an LFU entry.
+	Count        int
+	Age          int
+	ReferencedAt time.Time
+}
+
+// Snapshot is the contents of a cache as written by WriteSnapshot.
+type Snapshot[K comparable, V any] struct {
+	// Policy names the cache the snapshot was taken from, a snapshot only
+	// restores into a cache of the same policy.
+	Policy string
+
+	// State holds the counters of the policy that are not tied to an
+	// entry, e.g. the cache age of an LFU.
+	State []int64
+
+	// Entries are in the order the policy needs to rebuild its state.
+	Entries []SnapshotEntry[K, V]
+}
+
+// snapshotMagic starts every snapshot.
+const snapshotMagic = "FCSN"
+
+// snapshotVersion is the version of the snapshot format.
+const snapshotVersion = 1
+
+// WriteSnapshot writes s to w with codec, GobCodec if nil. The snapshot
+// starts with a versioned header naming the policy and the codec and ends
+// with a CRC-32 checksum.
+func WriteSnapshot[K comparable, V any](w io.Writer, codec Codec[K, V], s *Snapshot[K, V]) error {
+	if codec == nil {
+		codec = GobCodec[K, V]{}
+	}
+	var body bytes.Buffer
+	enc := codec.NewEncoder(&body)
+	for i := range s.Entries {
+		if err := enc.Encode(&s.Entries[i]); err != nil {
+			return fmt.Errorf("encode snapshot: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	buf.WriteByte(snapshotVersion)
+	writeString(&buf, s.Policy)
+	writeString(&buf, codec.Name())
+	buf.Write(binary.AppendUvarint(nil, uint64(len(s.State))))
+	for _, n := range s.State {
+		buf.Write(binary.AppendVarint(nil, n))
+	}
+	buf.Write(binary.AppendUvarint(nil, uint64(len(s.Entries))))
+	buf.Write(binary.AppendUvarint(nil, uint64(body.Len())))
+	buf.Write(body.Bytes())
+	buf.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(buf.Bytes())))
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	buf.WriteString(s)
+}
+
+// ErrInvalidSnapshot is returned when reading a snapshot that is corrupt,
+// truncated, not a snapshot, or of another policy or codec.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// ReadSnapshot reads a snapshot of the given policy written with codec,
+// GobCodec if nil, from r. It reads exactly the bytes of the snapshot.
+func ReadSnapshot[K comparable, V any](r io.Reader, policy string, codec Codec[K, V]) (*Snapshot[K, V], error) {
+	if codec == nil {
+		codec = GobCodec[K, V]{}
+	}
+	sr := &snapshotReader{r: r, crc: crc32.NewIEEE()}
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(sr, magic); err != nil || string(magic) != snapshotMagic {
+		return nil, ErrInvalidSnapshot
+	}
+	version, err := sr.ReadByte()
+	if err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, version)
+	}
+	s := &Snapshot[K, V]{}
+	if s.Policy, err = sr.readString(); err != nil {
+		return nil, err
+	}
+	name, err := sr.readString()
+	if err != nil {
+		return nil, err
+	}
+	n, err := sr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	for ; n > 0; n-- {
+		v, err := binary.ReadVarint(sr)
+		if err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		s.State = append(s.State, v)
+	}
+	count, err := sr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	size, err := sr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	// the body grows as it is read, so a corrupt size does not allocate
+	var body bytes.Buffer
+	if _, err := io.CopyN(&body, sr, int64(size)); err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	sum := sr.crc.Sum32()
+	var checksum [4]byte
+	if _, err := io.ReadFull(r, checksum[:]); err != nil || binary.BigEndian.Uint32(checksum[:]) != sum {
+		return nil, ErrInvalidSnapshot
+	}
+
+	if s.Policy != policy {
+		return nil, fmt.Errorf("%w: taken from a %s cache, not %s", ErrInvalidSnapshot, s.Policy, policy)
+	}
+	if name != codec.Name() {
+		return nil, fmt.Errorf("%w: encoded with %s, not %s", ErrInvalidSnapshot, name, codec.Name())
+	}
+	dec := codec.NewDecoder(&body)
+	s.Entries = make([]SnapshotEntry[K, V], min(count, 1<<16))[:0]
+	for ; count > 0; count-- {
+		var e SnapshotEntry[K, V]
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		s.Entries = append(s.Entries, e)
+	}
+	return s, nil
+}
+
+// snapshotReader reads a snapshot byte by byte where needed, so it never
+// reads past its end, and checksums what it reads.
+type snapshotReader struct {
+	r   io.Reader
+	crc hash.Hash32
+	b   [1]byte
+}
+
+func (r *snapshotReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.crc.Write(p[:n])
+	return n, err
+}
+
+func (r *snapshotReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r, r.b[:]); err != nil {
+		return 0, err
+	}
+	return r.b[0], nil
+}
+
+func (r *snapshotReader) readUvarint() (uint64, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, ErrInvalidSnapshot
+	}
+	return n, nil
+}
+
+func (r *snapshotReader) readString() (string, error) {
+	n, err := r.readUvarint()
+	if err != nil || n > 1<<10 {
+		return "", ErrInvalidSnapshot
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", ErrInvalidSnapshot
+	}
+	return string(b), nil
+}
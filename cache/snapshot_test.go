@@ -0,0 +1,115 @@
+package cache_test
+
+import (
+	"bytes"
+	"errors"
+	"fast-cache/cache"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testSnapshot() *cache.Snapshot[int, int] {
+	return &cache.Snapshot[int, int]{
+		Policy: "test",
+		State:  []int64{-3, 1 << 40},
+		Entries: []cache.SnapshotEntry[int, int]{
+			{Key: 1, Value: 10},
+			{Key: -2, Value: 20, Segment: 2, Count: 7, Age: 3, ReferencedAt: time.Unix(0, 12345)},
+		},
+	}
+}
+
+func TestSnapshot_Codecs(t *testing.T) {
+	binary, err := cache.NewBinaryCodec[int, int]()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, codec := range []cache.Codec[int, int]{nil, cache.GobCodec[int, int]{}, cache.JSONCodec[int, int]{}, binary} {
+		var buf bytes.Buffer
+		want := testSnapshot()
+		if err := cache.WriteSnapshot(&buf, codec, want); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		// data after the snapshot is not consumed
+		buf.WriteString("tail")
+		got, err := cache.ReadSnapshot(&buf, "test", codec)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for i := range got.Entries {
+			// the monotonic clock reading does not survive
+			if !got.Entries[i].ReferencedAt.Equal(want.Entries[i].ReferencedAt) {
+				t.Fatalf("bad time: %v", got.Entries[i].ReferencedAt)
+			}
+			got.Entries[i].ReferencedAt = want.Entries[i].ReferencedAt
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+		if buf.String() != "tail" {
+			t.Fatalf("bad tail: %q", buf.String())
+		}
+	}
+}
+
+func TestSnapshot_BinaryCodec(t *testing.T) {
+	type point struct{ X, Y float32 }
+	codec, err := cache.NewBinaryCodec[[2]uint16, point]()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var buf bytes.Buffer
+	want := &cache.Snapshot[[2]uint16, point]{
+		Policy:  "test",
+		Entries: []cache.SnapshotEntry[[2]uint16, point]{{Key: [2]uint16{1, 2}, Value: point{3, 4}}},
+	}
+	if err := cache.WriteSnapshot[[2]uint16, point](&buf, codec, want); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	got, err := cache.ReadSnapshot[[2]uint16, point](&buf, "test", codec)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if _, err := cache.NewBinaryCodec[string, int](); err == nil {
+		t.Fatalf("should fail on string keys")
+	}
+	if _, err := cache.NewBinaryCodec[int, []byte](); err == nil {
+		t.Fatalf("should fail on slice values")
+	}
+	if _, err := cache.NewBinaryCodec[int, struct{}](); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestSnapshot_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := cache.WriteSnapshot[int, int](&buf, nil, testSnapshot()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data := buf.Bytes()
+
+	corrupt := func(i int) []byte {
+		b := bytes.Clone(data)
+		b[i] ^= 0xff
+		return b
+	}
+	for name, b := range map[string][]byte{
+		"empty":     nil,
+		"magic":     corrupt(0),
+		"version":   corrupt(4),
+		"body":      corrupt(len(data) - 10),
+		"checksum":  corrupt(len(data) - 1),
+		"truncated": data[:len(data)-5],
+	} {
+		if _, err := cache.ReadSnapshot[int, int](bytes.NewReader(b), "test", nil); !errors.Is(err, cache.ErrInvalidSnapshot) {
+			t.Fatalf("%s: err %v", name, err)
+		}
+	}
+	if _, err := cache.ReadSnapshot[int, int](bytes.NewReader(data), "lru", nil); !errors.Is(err, cache.ErrInvalidSnapshot) {
+		t.Fatalf("should fail on another policy: %v", err)
+	}
+	if _, err := cache.ReadSnapshot[int, int](bytes.NewReader(data), "test", cache.JSONCodec[int, int]{}); !errors.Is(err, cache.ErrInvalidSnapshot) {
+		t.Fatalf("should fail on another codec: %v", err)
+	}
+}
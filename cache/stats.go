@@ -0,0 +1,153 @@
+package cache
+
+import "sync/atomic"
+
+// Stats is a snapshot of the counters of a cache.
+type Stats struct {
+	// Hits and Misses count Get lookups.
+	Hits   uint64
+	Misses uint64
+
+	// Adds counts keys newly inserted, Updates counts Add calls that
+	// overwrote the value of a key already cached.
+	Adds    uint64
+	Updates uint64
+
+	// Evictions counts entries that left the cache, indexed by EvictReason.
+	Evictions [numEvictReasons]uint64
+
+	// GhostHits counts Adds of keys found in a ghost list of recently
+	// evicted keys, e.g. the recentEvict list of TwoQueueCache.
+	GhostHits uint64
+
+	// Promotions counts entries moved from a recent list to a frequent one.
+	Promotions uint64
+}
+
+// StatsProvider is implemented by caches that can count Stats.
+type StatsProvider interface {
+	// EnableStats starts counting, it must be called before the cache is
+	// shared between goroutines.
+	EnableStats()
+
+	// Stats returns a snapshot of the counters, zero if stats are disabled.
+	Stats() Stats
+}
+
+// HitRatio returns the ratio of Get lookups that hit.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// TotalEvictions returns the number of entries evicted for any reason.
+func (s Stats) TotalEvictions() uint64 {
+	var n uint64
+	for _, e := range s.Evictions {
+		n += e
+	}
+	return n
+}
+
+// Merge adds the counters of o to s.
+func (s *Stats) Merge(o Stats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Adds += o.Adds
+	s.Updates += o.Updates
+	for i := range s.Evictions {
+		s.Evictions[i] += o.Evictions[i]
+	}
+	s.GhostHits += o.GhostHits
+	s.Promotions += o.Promotions
+}
+
+// StatsCounter counts Stats with atomic counters, so recording never takes
+// a lock. All methods are no-ops on a nil *StatsCounter, policies keep a
+// nil counter until stats are enabled.
+type StatsCounter struct {
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	adds       atomic.Uint64
+	updates    atomic.Uint64
+	evictions  [numEvictReasons]atomic.Uint64
+	ghostHits  atomic.Uint64
+	promotions atomic.Uint64
+}
+
+// Hit records a Get that found its key.
+func (s *StatsCounter) Hit() {
+	if s != nil {
+		s.hits.Add(1)
+	}
+}
+
+// Miss records a Get that did not find its key.
+func (s *StatsCounter) Miss() {
+	if s != nil {
+		s.misses.Add(1)
+	}
+}
+
+// Lookup records a Get as a hit or a miss.
+func (s *StatsCounter) Lookup(ok bool) {
+	if ok {
+		s.Hit()
+	} else {
+		s.Miss()
+	}
+}
+
+// Added records a new key inserted into the cache.
+func (s *StatsCounter) Added() {
+	if s != nil {
+		s.adds.Add(1)
+	}
+}
+
+// Updated records an Add that overwrote a cached key.
+func (s *StatsCounter) Updated() {
+	if s != nil {
+		s.updates.Add(1)
+	}
+}
+
+// Evicted records n entries leaving the cache for reason.
+func (s *StatsCounter) Evicted(reason EvictReason, n int) {
+	if s != nil && n > 0 {
+		s.evictions[reason].Add(uint64(n))
+	}
+}
+
+// GhostHit records an Add of a key found in a ghost list.
+func (s *StatsCounter) GhostHit() {
+	if s != nil {
+		s.ghostHits.Add(1)
+	}
+}
+
+// Promoted records an entry moved from a recent list to a frequent one.
+func (s *StatsCounter) Promoted() {
+	if s != nil {
+		s.promotions.Add(1)
+	}
+}
+
+// Snapshot returns the current counters.
+func (s *StatsCounter) Snapshot() (st Stats) {
+	if s == nil {
+		return st
+	}
+	st.Hits = s.hits.Load()
+	st.Misses = s.misses.Load()
+	st.Adds = s.adds.Load()
+	st.Updates = s.updates.Load()
+	for i := range st.Evictions {
+		st.Evictions[i] = s.evictions[i].Load()
+	}
+	st.GhostHits = s.ghostHits.Load()
+	st.Promotions = s.promotions.Load()
+	return st
+}
@@ -0,0 +1,92 @@
+package cache_test
+
+import (
+	"fast-cache/cache"
+	"fast-cache/lru"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	for name, c := range policies(t, 4) {
+		t.Run(name, func(t *testing.T) {
+			sp, ok := c.(cache.StatsProvider)
+			if !ok {
+				t.Fatalf("%T does not implement cache.StatsProvider", c)
+			}
+			c.Add(1, 1)
+			if st := sp.Stats(); st != (cache.Stats{}) {
+				t.Fatalf("Stats() before EnableStats = %+v", st)
+			}
+			sp.EnableStats()
+			c.Add(2, 2)
+			c.Add(2, 3)
+			c.Get(2)
+			c.Get(5)
+			c.Peek(1)
+			c.Contains(1)
+			c.Remove(1)
+			for i := 10; i < 20; i++ {
+				c.Add(i, i)
+			}
+			n := c.Len()
+			c.Purge()
+
+			st := sp.Stats()
+			if st.Hits != 1 || st.Misses != 1 {
+				t.Fatalf("Hits, Misses = %d, %d", st.Hits, st.Misses)
+			}
+			if st.HitRatio() != 0.5 {
+				t.Fatalf("HitRatio() = %v", st.HitRatio())
+			}
+			if st.Adds != 11 || st.Updates != 1 {
+				t.Fatalf("Adds, Updates = %d, %d", st.Adds, st.Updates)
+			}
+			if got := st.Evictions[cache.EvictReasonRemoved]; got != 1 {
+				t.Fatalf("removed = %d", got)
+			}
+			if got := st.Evictions[cache.EvictReasonPurged]; got != uint64(n) {
+				t.Fatalf("purged = %d, want %d", got, n)
+			}
+			// key 1 was added before EnableStats
+			if got := st.TotalEvictions(); got != st.Adds+1 {
+				t.Fatalf("TotalEvictions() = %d, want %d", got, st.Adds+1)
+			}
+		})
+	}
+}
+
+func TestStats_TwoQueue(t *testing.T) {
+	c, err := lru.New2Q[int, int](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.EnableStats()
+	for i := 0; i < 6; i++ {
+		c.Add(i, i)
+	}
+	c.Get(5)
+	// 1 was evicted from the recent list into recentEvict
+	c.Add(1, 1)
+
+	st := c.Stats()
+	if st.GhostHits != 1 {
+		t.Fatalf("GhostHits = %d", st.GhostHits)
+	}
+	if st.Promotions != 1 {
+		t.Fatalf("Promotions = %d", st.Promotions)
+	}
+	if st.Evictions[cache.EvictReasonCapacity] == 0 {
+		t.Fatalf("no capacity evictions")
+	}
+}
+
+func TestStats_Merge(t *testing.T) {
+	a := cache.Stats{Hits: 1, Misses: 2}
+	a.Evictions[cache.EvictReasonExpired] = 3
+	b := cache.Stats{Hits: 4, Adds: 5}
+	b.Evictions[cache.EvictReasonExpired] = 1
+	a.Merge(b)
+	if a.Hits != 5 || a.Misses != 2 || a.Adds != 5 || a.TotalEvictions() != 4 {
+		t.Fatalf("Merge = %+v", a)
+	}
+}
@@ -3,8 +3,13 @@ package clock
 import (
 	"container/ring"
 	"errors"
+	"fast-cache/cache"
 )
 
+var _ cache.Cache[int, int] = (*Clock[int, int])(nil)
+var _ cache.StatsProvider = (*Clock[int, int])(nil)
+var _ cache.CapacityProvider = (*Clock[int, int])(nil)
+
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback[K comparable, V any] func(key K, value V)
 
@@ -20,6 +25,8 @@ type Clock[K comparable, V any] struct {
 	hand    *ring.Ring
 	head    *ring.Ring
 	onEvict EvictCallback[K, V]
+	stats   *cache.StatsCounter
+	admit   cache.Admitter[K]
 }
 
 // NewClock constructs an Clock of the given size
@@ -42,14 +49,26 @@ func NewClock[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*Cloc
 //
 // If value satisfies "interface{ GetReferenceCount() int }", the value of
 // the GetReferenceCount() method is used to set the initial value of reference count.
-func (c *Clock[K, V]) Add(key K, val V) {
+func (c *Clock[K, V]) Add(key K, val V) (evicted bool) {
+	c.admit.Record(key)
 	if e, ok := c.items[key]; ok {
 		entry := e.Value.(*CEntry[K, V])
 		entry.refCount++
 		entry.Val = val
-		return
+		c.stats.Updated()
+		return false
+	}
+	if c.admit.Enabled() && len(c.items) >= c.size {
+		// a rejected candidate must leave the clock as it was
+		if v := c.victim(); v != nil && !c.admit.Admit(key, v.Value.(*CEntry[K, V]).Key) {
+			return false
+		}
+	}
+	if len(c.items) < c.size {
+		c.hand = freeSlot(c.hand)
+	} else {
+		evicted = c.evict()
 	}
-	c.evict()
 	c.hand.Value = &CEntry[K, V]{
 		Key:      key,
 		Val:      val,
@@ -57,66 +76,239 @@ func (c *Clock[K, V]) Add(key K, val V) {
 	}
 	c.items[key] = c.hand
 	c.hand = c.hand.Next()
+	c.stats.Added()
+	return evicted
 }
 
 // Get looks up a key's value from the cache.
 func (c *Clock[K, V]) Get(key K) (value V, ok bool) {
+	c.admit.Record(key)
 	if ent, ok := c.items[key]; ok {
 		entry := ent.Value.(*CEntry[K, V])
 		entry.refCount++
+		c.stats.Hit()
 		return entry.Val, true
 	}
+	c.stats.Miss()
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the reference count of the key.
+func (c *Clock[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value.(*CEntry[K, V]).Val, true
+	}
 	return
 }
 
-func (c *Clock[K, V]) evict() {
+// Contains checks if a key is in the cache, without updating the reference count.
+func (c *Clock[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// sweep advances the hand, decrementing reference counts, until it rests
+// on an empty slot or on an entry whose count dropped to zero.
+func (c *Clock[K, V]) sweep() {
 	for c.hand.Value != nil && c.hand.Value.(*CEntry[K, V]).refCount > 0 {
 		c.hand.Value.(*CEntry[K, V]).refCount--
 		c.hand = c.hand.Next()
 	}
+}
+
+// victim returns the slot the next sweep stops at without moving the hand
+// or touching reference counts: the first entry from the hand with the
+// fewest references, as the sweep takes one from each entry it passes.
+func (c *Clock[K, V]) victim() *ring.Ring {
+	var victim *ring.Ring
+	least := 0
+	for i, r := 0, c.hand; i < c.size; i, r = i+1, r.Next() {
+		if r.Value == nil {
+			// the sweep stops at an empty slot first
+			return nil
+		}
+		e := r.Value.(*CEntry[K, V])
+		if n := e.refCount; victim == nil || n < least {
+			victim, least = r, n
+			if n == 0 {
+				break
+			}
+		}
+	}
+	return victim
+}
+
+// evict removes the entry under the hand once its reference count drops to
+// zero. Returns true if an entry was evicted.
+func (c *Clock[K, V]) evict() bool {
+	c.sweep()
 	if c.hand.Value != nil {
 		entry := c.hand.Value.(*CEntry[K, V])
 		delete(c.items, entry.Key)
 		c.hand.Value = nil
+		c.stats.Evicted(cache.EvictReasonCapacity, 1)
 		if c.onEvict != nil {
 			c.onEvict(entry.Key, entry.Val)
 		}
+		return true
 	}
+	return false
 }
 
 // Keys returns the keys of the cache. the order as same as current ring order.
-func (c *Clock[K, V]) Keys() []K {
-	keys := make([]K, 0, len(c.items))
-	r := c.head
-	if r.Value == nil {
-		return []K{}
-	}
-	// the first element
-	keys = append(keys, r.Value.(*CEntry[K, V]).Key)
-
-	// iterating
-	for p := c.head.Next(); p != r; p = p.Next() {
-		if p.Value == nil {
-			continue
-		}
-		e := p.Value.(*CEntry[K, V])
-		keys = append(keys, e.Key)
+func (c *Clock[K, V]) Keys(reverse bool) []K {
+	entries := ringEntries[CEntry[K, V]](c.head, len(c.items), reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
 	}
 	return keys
 }
 
-// Delete deletes the item with provided key from the cache.
-func (c *Clock[K, V]) Delete(key K) {
+// Values returns the values of the cache. the order as same as Keys.
+func (c *Clock[K, V]) Values(reverse bool) []V {
+	entries := ringEntries[CEntry[K, V]](c.head, len(c.items), reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Val
+	}
+	return values
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Clock[K, V]) Remove(key K) (present bool) {
 	if e, ok := c.items[key]; ok {
+		entry := e.Value.(*CEntry[K, V])
 		delete(c.items, key)
 		e.Value = nil
+		c.stats.Evicted(cache.EvictReasonRemoved, 1)
 		if c.onEvict != nil {
-			c.onEvict(e.Value.(*CEntry[K, V]).Key, e.Value.(*CEntry[K, V]).Val)
+			c.onEvict(entry.Key, entry.Val)
 		}
+		return true
 	}
+	return false
+}
+
+// Delete deletes the item with provided key from the cache.
+//
+// Deprecated: use Remove.
+func (c *Clock[K, V]) Delete(key K) {
+	c.Remove(key)
 }
 
 // Len returns the number of items in the cache.
 func (c *Clock[K, V]) Len() int {
 	return len(c.items)
 }
+
+// Cap returns the size of the cache.
+func (c *Clock[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache.
+func (c *Clock[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, e := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, e.Value.(*CEntry[K, V]).Val)
+		}
+		delete(c.items, k)
+	}
+	r := ring.New(c.size)
+	c.head, c.hand = r, r
+}
+
+// Resize changes the cache size. Entries keep their reference counts and
+// ring order, starting from the current hand.
+func (c *Clock[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	for len(c.items) > size {
+		if c.evict() {
+			evicted++
+		} else {
+			c.hand = c.hand.Next()
+		}
+	}
+	c.head, c.hand = resizeRing(c.hand, size, c.items)
+	c.size = size
+	return evicted, nil
+}
+
+// freeSlot returns the first empty slot of the ring from hand on, so a
+// slot left by a removed entry is reused instead of evicting. The ring must
+// have an empty slot.
+func freeSlot(hand *ring.Ring) *ring.Ring {
+	for hand.Value != nil {
+		hand = hand.Next()
+	}
+	return hand
+}
+
+// ringEntries returns the occupied slots of the ring starting at head,
+// walking backwards from the slot before head if reverse is set.
+func ringEntries[E any](head *ring.Ring, n int, reverse bool) []*E {
+	entries := make([]*E, 0, n)
+	p := head
+	if reverse {
+		p = head.Prev()
+	}
+	for i, l := 0, head.Len(); i < l; i++ {
+		if p.Value != nil {
+			entries = append(entries, p.Value.(*E))
+		}
+		if reverse {
+			p = p.Prev()
+		} else {
+			p = p.Next()
+		}
+	}
+	return entries
+}
+
+// resizeRing copies the occupied slots of the ring, starting at hand, into a
+// new ring of the given size and re-points items at the new slots. The
+// caller must have evicted down to size first. Returns the new head and the
+// slot following the last copied entry, which becomes the new hand.
+func resizeRing[K comparable](hand *ring.Ring, size int, items map[K]*ring.Ring) (head, next *ring.Ring) {
+	head = ring.New(size)
+	next = head
+	moved := make(map[*ring.Ring]*ring.Ring, len(items))
+	p := hand
+	for i, l := 0, hand.Len(); i < l; i++ {
+		if p.Value != nil {
+			next.Value = p.Value
+			moved[p] = next
+			next = next.Next()
+		}
+		p = p.Next()
+	}
+	for k, r := range items {
+		items[k] = moved[r]
+	}
+	return head, next
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *Clock[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Clock[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// SetAdmission installs the admission policy consulted before a new key
+// evicts an entry, nil admits every key.
+func (c *Clock[K, V]) SetAdmission(admission cache.Admission[K]) {
+	c.admit.Set(admission)
+}
@@ -0,0 +1,389 @@
+package clock
+
+import (
+	"container/ring"
+	"errors"
+	"fast-cache/cache"
+)
+
+var _ cache.Cache[int, int] = (*ClockPro[int, int])(nil)
+var _ cache.StatsProvider = (*ClockPro[int, int])(nil)
+var _ cache.CapacityProvider = (*ClockPro[int, int])(nil)
+
+// pageType is the state of a ClockPro entry.
+type pageType uint8
+
+const (
+	pageCold pageType = iota // resident, evicted by the cold hand
+	pageHot                  // resident, demoted to cold by the hot hand
+	pageTest                 // non-resident, only the key is kept
+)
+
+// minProSize is the smallest ClockPro: with a single entry the cold target
+// leaves no room for a hot one, so every promotion would be demoted at once.
+const minProSize = 2
+
+type ProEntry[K comparable, V any] struct {
+	Key  K
+	Val  V
+	page pageType
+	ref  bool
+}
+
+// ClockPro implements a non-thread safe fixed size CLOCK-Pro cache. Hot and
+// cold resident entries share one clock with non-resident test entries that
+// remember recently evicted keys. The cold hand evicts unreferenced cold
+// entries, keeping their keys as test entries, and promotes referenced ones
+// to hot; the hot hand demotes unreferenced hot entries to cold once the
+// hot ones exceed their share; the test hand forgets test entries. A key
+// added back while it is a test entry comes back hot and grows the cold
+// target, a test entry forgotten shrinks it, so the split between hot and
+// cold adapts to the workload. Hits only set a reference bit.
+type ClockPro[K comparable, V any] struct {
+	size       int
+	coldTarget int // resident cold entries wanted, the rest may be hot
+	items      map[K]*ring.Ring
+
+	handHot  *ring.Ring
+	handCold *ring.Ring
+	handTest *ring.Ring
+
+	countHot  int
+	countCold int
+	countTest int
+
+	onEvict EvictCallback[K, V]
+	stats   *cache.StatsCounter
+}
+
+// NewClockPro constructs a ClockPro of the given size, it remembers at most
+// size non-resident keys. The size must leave room for a hot and a cold
+// entry, so it is at least 2.
+func NewClockPro[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*ClockPro[K, V], error) {
+	if size < minProSize {
+		return nil, errors.New("size must be at least 2")
+	}
+	c := &ClockPro[K, V]{
+		size:       size,
+		coldTarget: size,
+		items:      make(map[K]*ring.Ring, 2*size),
+		onEvict:    onEvict,
+	}
+	return c, nil
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *ClockPro[K, V]) Add(key K, val V) (evicted bool) {
+	r, ok := c.items[key]
+	if !ok {
+		r = ring.New(1)
+		r.Value = &ProEntry[K, V]{Key: key, Val: val, page: pageCold}
+		evicted = c.link(key, r)
+		c.countCold++
+		c.stats.Added()
+		return evicted
+	}
+
+	entry := r.Value.(*ProEntry[K, V])
+	if entry.page != pageTest {
+		entry.Val = val
+		entry.ref = true
+		c.stats.Updated()
+		return false
+	}
+
+	// the key was evicted recently, so cold entries are kept too briefly
+	if c.coldTarget < c.size {
+		c.coldTarget++
+	}
+	c.unlink(r)
+	c.countTest--
+	evicted = c.link(key, r)
+	entry.Val, entry.page, entry.ref = val, pageHot, false
+	c.countHot++
+	c.stats.GhostHit()
+	c.stats.Added()
+	return evicted
+}
+
+// link makes room for a resident entry and inserts r for key behind the hot
+// hand, the head of the clock. Returns true if an eviction occurred.
+func (c *ClockPro[K, V]) link(key K, r *ring.Ring) (evicted bool) {
+	for c.countHot+c.countCold >= c.size {
+		c.runHandCold()
+		evicted = true
+	}
+	c.items[key] = r
+	if c.handHot == nil {
+		c.handHot, c.handCold, c.handTest = r, r, r
+		return evicted
+	}
+	r.Link(c.handHot)
+	if c.handCold == c.handHot {
+		c.handCold = c.handCold.Prev()
+	}
+	return evicted
+}
+
+// unlink removes r from the clock, moving the hands on it back.
+func (c *ClockPro[K, V]) unlink(r *ring.Ring) {
+	delete(c.items, r.Value.(*ProEntry[K, V]).Key)
+	if len(c.items) == 0 {
+		c.handHot, c.handCold, c.handTest = nil, nil, nil
+		return
+	}
+	if r == c.handHot {
+		c.handHot = c.handHot.Prev()
+	}
+	if r == c.handCold {
+		c.handCold = c.handCold.Prev()
+	}
+	if r == c.handTest {
+		c.handTest = c.handTest.Prev()
+	}
+	r.Prev().Unlink(1)
+}
+
+// runHandCold moves the cold hand one entry, then the hot hand until the hot
+// entries are within their share.
+func (c *ClockPro[K, V]) runHandCold() {
+	c.stepHandCold()
+	for c.countHot > c.size-c.coldTarget {
+		c.runHandHot()
+	}
+}
+
+// stepHandCold moves the cold hand one entry. An unreferenced cold entry is
+// evicted and becomes a test entry, a referenced one is promoted to hot.
+func (c *ClockPro[K, V]) stepHandCold() {
+	entry := c.handCold.Value.(*ProEntry[K, V])
+	if entry.page == pageCold {
+		if entry.ref {
+			entry.page, entry.ref = pageHot, false
+			c.countCold--
+			c.countHot++
+			c.stats.Promoted()
+		} else {
+			val := entry.Val
+			var zero V
+			entry.page, entry.Val = pageTest, zero
+			c.countCold--
+			c.countTest++
+			c.stats.Evicted(cache.EvictReasonCapacity, 1)
+			if c.onEvict != nil {
+				c.onEvict(entry.Key, val)
+			}
+			for c.countTest > c.size {
+				c.runHandTest()
+			}
+		}
+	}
+	c.handCold = c.handCold.Next()
+}
+
+// runHandHot moves the hot hand one entry, demoting an unreferenced hot
+// entry to cold. Test entries it passes are forgotten first.
+func (c *ClockPro[K, V]) runHandHot() {
+	if c.handHot == c.handTest {
+		c.runHandTest()
+	}
+	entry := c.handHot.Value.(*ProEntry[K, V])
+	if entry.page == pageHot {
+		if entry.ref {
+			entry.ref = false
+		} else {
+			entry.page = pageCold
+			c.countHot--
+			c.countCold++
+		}
+	}
+	c.handHot = c.handHot.Next()
+}
+
+// runHandTest moves the test hand one entry, forgetting a test entry and
+// shrinking the cold target. Only the cold hand is moved out of its way, not
+// the hot one, as the hot hand may be what runs the test hand.
+func (c *ClockPro[K, V]) runHandTest() {
+	if c.handTest == c.handCold {
+		c.stepHandCold()
+	}
+	if entry := c.handTest.Value.(*ProEntry[K, V]); entry.page == pageTest {
+		r := c.handTest
+		c.handTest = r.Prev()
+		c.unlink(r)
+		c.countTest--
+		if c.coldTarget > 1 {
+			c.coldTarget--
+		}
+		if c.handTest == nil {
+			return
+		}
+	}
+	c.handTest = c.handTest.Next()
+}
+
+// Get looks up a key's value from the cache.
+func (c *ClockPro[K, V]) Get(key K) (value V, ok bool) {
+	if r, ok := c.items[key]; ok {
+		if entry := r.Value.(*ProEntry[K, V]); entry.page != pageTest {
+			entry.ref = true
+			c.stats.Hit()
+			return entry.Val, true
+		}
+	}
+	c.stats.Miss()
+	return
+}
+
+// resident returns the resident entry of key, nil if it is not cached.
+func (c *ClockPro[K, V]) resident(key K) *ProEntry[K, V] {
+	if r, ok := c.items[key]; ok {
+		if entry := r.Value.(*ProEntry[K, V]); entry.page != pageTest {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Peek returns the key value (or undefined if not found) without setting
+// the reference bit of the key.
+func (c *ClockPro[K, V]) Peek(key K) (value V, ok bool) {
+	if entry := c.resident(key); entry != nil {
+		return entry.Val, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without setting the reference
+// bit.
+func (c *ClockPro[K, V]) Contains(key K) bool {
+	return c.resident(key) != nil
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained. A test entry of the key is forgotten.
+func (c *ClockPro[K, V]) Remove(key K) (present bool) {
+	r, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	entry := r.Value.(*ProEntry[K, V])
+	c.unlink(r)
+	switch entry.page {
+	case pageTest:
+		c.countTest--
+		return false
+	case pageHot:
+		c.countHot--
+	case pageCold:
+		c.countCold--
+	}
+	c.stats.Evicted(cache.EvictReasonRemoved, 1)
+	if c.onEvict != nil {
+		c.onEvict(entry.Key, entry.Val)
+	}
+	return true
+}
+
+// entries returns the resident entries in clock order, starting at the
+// hot hand, walking backwards from the entry before it if reverse is set.
+func (c *ClockPro[K, V]) entries(reverse bool) []*ProEntry[K, V] {
+	entries := make([]*ProEntry[K, V], 0, c.Len())
+	if c.handHot == nil {
+		return entries
+	}
+	p := c.handHot
+	if reverse {
+		p = p.Prev()
+	}
+	for i, l := 0, len(c.items); i < l; i++ {
+		if entry := p.Value.(*ProEntry[K, V]); entry.page != pageTest {
+			entries = append(entries, entry)
+		}
+		if reverse {
+			p = p.Prev()
+		} else {
+			p = p.Next()
+		}
+	}
+	return entries
+}
+
+// Keys returns the keys of the cache in clock order, starting at the hot
+// hand.
+func (c *ClockPro[K, V]) Keys(reverse bool) []K {
+	entries := c.entries(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Values returns the values of the cache. the order as same as Keys.
+func (c *ClockPro[K, V]) Values(reverse bool) []V {
+	entries := c.entries(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Val
+	}
+	return values
+}
+
+// Len returns the number of resident items in the cache.
+func (c *ClockPro[K, V]) Len() int {
+	return c.countHot + c.countCold
+}
+
+// Cap returns the size of the cache.
+func (c *ClockPro[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache and its test entries.
+func (c *ClockPro[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, c.Len())
+	for k, r := range c.items {
+		if entry := r.Value.(*ProEntry[K, V]); entry.page != pageTest && c.onEvict != nil {
+			c.onEvict(k, entry.Val)
+		}
+		delete(c.items, k)
+	}
+	c.handHot, c.handCold, c.handTest = nil, nil, nil
+	c.countHot, c.countCold, c.countTest = 0, 0, 0
+	c.coldTarget = c.size
+}
+
+// Resize changes the cache size, and the number of test entries kept. The
+// size is at least 2.
+func (c *ClockPro[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	if size < minProSize {
+		return 0, errors.New("size must be at least 2")
+	}
+	n := c.Len()
+	c.size = size
+	c.coldTarget = min(c.coldTarget, size)
+	for c.Len() > size {
+		c.runHandCold()
+	}
+	for c.countTest > size {
+		c.runHandTest()
+	}
+	return n - c.Len(), nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *ClockPro[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *ClockPro[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
@@ -0,0 +1,189 @@
+package clock
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkClockPro verifies the counters of c against its clock.
+func checkClockPro[K comparable, V any](t *testing.T, c *ClockPro[K, V]) {
+	t.Helper()
+	var hot, cold, test int
+	if c.handHot != nil {
+		for i, r := 0, c.handHot; i < r.Len(); i++ {
+			switch r.Value.(*ProEntry[K, V]).page {
+			case pageHot:
+				hot++
+			case pageCold:
+				cold++
+			case pageTest:
+				test++
+			}
+			r = r.Next()
+		}
+	}
+	if hot != c.countHot || cold != c.countCold || test != c.countTest {
+		t.Fatalf("bad counts: hot %d/%d cold %d/%d test %d/%d", hot, c.countHot, cold, c.countCold, test, c.countTest)
+	}
+	if hot+cold+test != len(c.items) || hot+cold > c.size || test > c.size {
+		t.Fatalf("bad sizes: hot %d cold %d test %d items %d size %d", hot, cold, test, len(c.items), c.size)
+	}
+	if c.coldTarget < 1 || c.coldTarget > c.size {
+		t.Fatalf("bad cold target: %d", c.coldTarget)
+	}
+}
+
+func TestClockPro(t *testing.T) {
+	var evicted []int
+	c, err := NewClockPro[int, int](4, func(k, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.EnableStats()
+	for i := 1; i <= 4; i++ {
+		c.Add(i, i)
+	}
+	if keys := c.Keys(false); len(keys) != 4 || keys[0] != 1 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if !c.Add(5, 5) || len(evicted) != 1 || c.Len() != 4 {
+		t.Fatalf("should evict one: %v", evicted)
+	}
+	checkClockPro(t, c)
+
+	// the evicted key is remembered and comes back hot
+	gone := evicted[0]
+	if c.Contains(gone) {
+		t.Fatalf("%d should not be resident", gone)
+	}
+	if _, ok := c.Get(gone); ok {
+		t.Fatalf("%d should miss", gone)
+	}
+	target := c.coldTarget
+	c.Add(gone, gone)
+	if e := c.items[gone].Value.(*ProEntry[int, int]); e.page != pageHot {
+		t.Fatalf("%d should be hot", gone)
+	}
+	if c.coldTarget != min(target+1, c.size) {
+		t.Fatalf("bad cold target: %d", c.coldTarget)
+	}
+	if s := c.Stats(); s.GhostHits != 1 || s.Adds != 6 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	checkClockPro(t, c)
+
+	if !c.Remove(gone) || c.Remove(gone) {
+		t.Fatalf("bad remove")
+	}
+	checkClockPro(t, c)
+	if _, err := c.Resize(1); err == nil {
+		t.Fatalf("resize to 1 should fail")
+	}
+	if evicted, _ := c.Resize(2); evicted != 1 || c.Len() != 2 {
+		t.Fatalf("bad resize: %d %d", evicted, c.Len())
+	}
+	checkClockPro(t, c)
+	c.Purge()
+	if c.Len() != 0 || len(c.items) != 0 {
+		t.Fatalf("bad purge")
+	}
+	c.Add(1, 1)
+	checkClockPro(t, c)
+}
+
+func TestClockPro_RandomOps(t *testing.T) {
+	c, err := NewClockPro[int, int](64, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		key := r.Intn(256)
+		switch r.Intn(4) {
+		case 0, 1:
+			c.Add(key, key)
+		case 2:
+			c.Get(key)
+		case 3:
+			c.Remove(key)
+		}
+		if i%997 == 0 {
+			checkClockPro(t, c)
+		}
+	}
+	checkClockPro(t, c)
+}
+
+func TestClockPro_SizeOne(t *testing.T) {
+	// a single entry leaves no room for a hot one, promoting it used to
+	// recurse through the hands without moving them
+	if _, err := NewClockPro[int, int](1, nil); err == nil {
+		t.Fatalf("size 1 should fail")
+	}
+	c, err := NewClockPro[int, int](2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := c.Resize(1); err == nil || c.size != 2 {
+		t.Fatalf("resize to 1 should fail")
+	}
+	c.Add(0, 0)
+	c.Get(0)
+	for i := 100; i < 110; i++ {
+		c.Add(i, i)
+		checkClockPro(t, c)
+	}
+}
+
+func TestClockPro_SmallRandomOps(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for size := 2; size <= 8; size++ {
+		c, err := NewClockPro[int, int](size, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for i := 0; i < 20000; i++ {
+			key := r.Intn(size * 3)
+			switch r.Intn(4) {
+			case 0, 1:
+				c.Add(key, key)
+			case 2:
+				c.Get(key)
+			case 3:
+				c.Remove(key)
+			}
+			checkClockPro(t, c)
+		}
+	}
+}
+
+func TestClockPro_Loop(t *testing.T) {
+	// a loop slightly larger than the cache defeats CLOCK, CLOCK-Pro keeps
+	// part of it hot
+	pro, err := NewClockPro[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	clk, err := NewClock[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var proHits, clkHits int
+	for i := 0; i < 20000; i++ {
+		key := i % 120
+		if _, ok := pro.Get(key); ok {
+			proHits++
+		} else {
+			pro.Add(key, key)
+		}
+		if _, ok := clk.Get(key); ok {
+			clkHits++
+		} else {
+			clk.Add(key, key)
+		}
+	}
+	t.Logf("hits: clock-pro %d clock %d", proHits, clkHits)
+	if proHits <= clkHits {
+		t.Fatalf("clock-pro should beat clock: %d <= %d", proHits, clkHits)
+	}
+}
@@ -3,6 +3,7 @@ package clock
 import (
 	"container/ring"
 	"errors"
+	"fast-cache/cache"
 )
 
 type CSEntry[K comparable, V any] struct {
@@ -12,12 +13,18 @@ type CSEntry[K comparable, V any] struct {
 	useCount int
 }
 
+var _ cache.Cache[int, int] = (*ClockSweep[int, int])(nil)
+var _ cache.StatsProvider = (*ClockSweep[int, int])(nil)
+var _ cache.CapacityProvider = (*ClockSweep[int, int])(nil)
+
 type ClockSweep[K comparable, V any] struct {
 	size    int
 	items   map[K]*ring.Ring
 	hand    *ring.Ring
 	head    *ring.Ring
 	onEvict EvictCallback[K, V]
+	stats   *cache.StatsCounter
+	admit   cache.Admitter[K]
 }
 
 // NewClockSweep constructs an Clock of the given size
@@ -40,14 +47,26 @@ func NewClockSweep[K comparable, V any](size int, onEvict EvictCallback[K, V]) (
 //
 // If value satisfies "interface{ GetReferenceCount() int }", the value of
 // the GetReferenceCount() method is used to set the initial value of reference count.
-func (c *ClockSweep[K, V]) Add(key K, val V) {
+func (c *ClockSweep[K, V]) Add(key K, val V) (evicted bool) {
+	c.admit.Record(key)
 	if e, ok := c.items[key]; ok {
 		entry := e.Value.(*CSEntry[K, V])
 		entry.useCount++
 		entry.Val = val
-		return
+		c.stats.Updated()
+		return false
+	}
+	if c.admit.Enabled() && len(c.items) >= c.size {
+		// a rejected candidate must leave the clock as it was
+		if v := c.victim(); v != nil && !c.admit.Admit(key, v.Value.(*CSEntry[K, V]).Key) {
+			return false
+		}
+	}
+	if len(c.items) < c.size {
+		c.hand = freeSlot(c.hand)
+	} else {
+		evicted = c.evict()
 	}
-	c.evict()
 	c.hand.Value = &CSEntry[K, V]{
 		Key:      key,
 		Val:      val,
@@ -56,19 +75,41 @@ func (c *ClockSweep[K, V]) Add(key K, val V) {
 	}
 	c.items[key] = c.hand
 	c.hand = c.hand.Next()
+	c.stats.Added()
+	return evicted
 }
 
 // Get looks up a key's value from the cache.
 func (c *ClockSweep[K, V]) Get(key K) (value V, ok bool) {
+	c.admit.Record(key)
 	if ent, ok := c.items[key]; ok {
 		entry := ent.Value.(*CSEntry[K, V])
 		entry.useCount++
+		c.stats.Hit()
 		return entry.Val, true
 	}
+	c.stats.Miss()
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the usage of the key.
+func (c *ClockSweep[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value.(*CSEntry[K, V]).Val, true
+	}
 	return
 }
 
-func (c *ClockSweep[K, V]) evict() {
+// Contains checks if a key is in the cache, without updating the usage.
+func (c *ClockSweep[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// sweep advances the hand, decrementing usage, until it rests on an empty
+// slot or on an evictable entry.
+func (c *ClockSweep[K, V]) sweep() {
 	for c.hand.Value != nil {
 		if c.hand.Value.(*CSEntry[K, V]).refCount == 0 {
 			if c.hand.Value.(*CSEntry[K, V]).useCount > 0 {
@@ -83,46 +124,147 @@ func (c *ClockSweep[K, V]) evict() {
 			c.hand = c.hand.Next()
 		}
 	}
+}
+
+// victim returns the slot the next sweep stops at without moving the hand
+// or touching counts: the first entry from the hand with the lowest sum of
+// reference and use counts, as the sweep takes one from each entry it
+// passes.
+func (c *ClockSweep[K, V]) victim() *ring.Ring {
+	var victim *ring.Ring
+	least := 0
+	for i, r := 0, c.hand; i < c.size; i, r = i+1, r.Next() {
+		if r.Value == nil {
+			// the sweep stops at an empty slot first
+			return nil
+		}
+		e := r.Value.(*CSEntry[K, V])
+		if n := e.refCount + e.useCount; victim == nil || n < least {
+			victim, least = r, n
+			if n == 0 {
+				break
+			}
+		}
+	}
+	return victim
+}
+
+// evict removes the first evictable entry found by the hand.
+// Returns true if an entry was evicted.
+func (c *ClockSweep[K, V]) evict() bool {
+	c.sweep()
 	if c.hand.Value != nil {
 		entry := c.hand.Value.(*CSEntry[K, V])
 		delete(c.items, entry.Key)
 		c.hand.Value = nil
+		c.stats.Evicted(cache.EvictReasonCapacity, 1)
+		if c.onEvict != nil {
+			c.onEvict(entry.Key, entry.Val)
+		}
+		return true
 	}
+	return false
 }
 
 // Keys returns the keys of the cache. the order as same as current ring order.
-func (c *ClockSweep[K, V]) Keys() []K {
-	keys := make([]K, 0, len(c.items))
-	r := c.head
-	if r.Value == nil {
-		return []K{}
-	}
-	// the first element
-	keys = append(keys, r.Value.(*CSEntry[K, V]).Key)
-
-	// iterating
-	for p := c.head.Next(); p != r; p = p.Next() {
-		if p.Value == nil {
-			continue
-		}
-		e := p.Value.(*CSEntry[K, V])
-		keys = append(keys, e.Key)
+func (c *ClockSweep[K, V]) Keys(reverse bool) []K {
+	entries := ringEntries[CSEntry[K, V]](c.head, len(c.items), reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
 	}
 	return keys
 }
 
-// Delete deletes the item with provided key from the cache.
-func (c *ClockSweep[K, V]) Delete(key K) {
+// Values returns the values of the cache. the order as same as Keys.
+func (c *ClockSweep[K, V]) Values(reverse bool) []V {
+	entries := ringEntries[CSEntry[K, V]](c.head, len(c.items), reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Val
+	}
+	return values
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *ClockSweep[K, V]) Remove(key K) (present bool) {
 	if e, ok := c.items[key]; ok {
+		entry := e.Value.(*CSEntry[K, V])
 		delete(c.items, key)
 		e.Value = nil
+		c.stats.Evicted(cache.EvictReasonRemoved, 1)
 		if c.onEvict != nil {
-			c.onEvict(e.Value.(*CSEntry[K, V]).Key, e.Value.(*CSEntry[K, V]).Val)
+			c.onEvict(entry.Key, entry.Val)
 		}
+		return true
 	}
+	return false
+}
+
+// Delete deletes the item with provided key from the cache.
+//
+// Deprecated: use Remove.
+func (c *ClockSweep[K, V]) Delete(key K) {
+	c.Remove(key)
 }
 
 // Len returns the number of items in the cache.
 func (c *ClockSweep[K, V]) Len() int {
 	return len(c.items)
 }
+
+// Cap returns the size of the cache.
+func (c *ClockSweep[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache.
+func (c *ClockSweep[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, e := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, e.Value.(*CSEntry[K, V]).Val)
+		}
+		delete(c.items, k)
+	}
+	r := ring.New(c.size)
+	c.head, c.hand = r, r
+}
+
+// Resize changes the cache size. Entries keep their usage and ring order,
+// starting from the current hand.
+func (c *ClockSweep[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	for len(c.items) > size {
+		if c.evict() {
+			evicted++
+		} else {
+			c.hand = c.hand.Next()
+		}
+	}
+	c.head, c.hand = resizeRing(c.hand, size, c.items)
+	c.size = size
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *ClockSweep[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *ClockSweep[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// SetAdmission installs the admission policy consulted before a new key
+// evicts an entry, nil admits every key.
+func (c *ClockSweep[K, V]) SetAdmission(admission cache.Admission[K]) {
+	c.admit.Set(admission)
+}
@@ -1,8 +1,12 @@
 package clock
 
 import (
+	"container/ring"
+	"fast-cache/cache"
 	"fmt"
+	"math/rand"
 	"testing"
+	"time"
 )
 
 func TestSet(t *testing.T) {
@@ -131,3 +135,371 @@ func TestWSClock_Add(t *testing.T) {
 	// key 'a' has been deleted
 	// 3
 }
+
+// clocks returns a constructor of every clock cache.
+func clocks() map[string]func(size int, onEvict EvictCallback[int, int]) (cache.Cache[int, int], error) {
+	return map[string]func(int, EvictCallback[int, int]) (cache.Cache[int, int], error){
+		"clock": func(size int, onEvict EvictCallback[int, int]) (cache.Cache[int, int], error) {
+			return NewClock[int, int](size, onEvict)
+		},
+		"clock-sweep": func(size int, onEvict EvictCallback[int, int]) (cache.Cache[int, int], error) {
+			return NewClockSweep[int, int](size, onEvict)
+		},
+		"wsclock": func(size int, onEvict EvictCallback[int, int]) (cache.Cache[int, int], error) {
+			return NewWSClock[int, int](size, onEvict)
+		},
+	}
+}
+
+func TestClocks_OnEvict(t *testing.T) {
+	for name, newClock := range clocks() {
+		t.Run(name, func(t *testing.T) {
+			evicted := make(map[int]int)
+			c, err := newClock(2, func(k, v int) { evicted[k] = v })
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			c.Add(1, 10)
+			c.Add(2, 20)
+			if !c.Add(3, 30) || len(evicted) != 1 {
+				t.Fatalf("bad evicted: %v", evicted)
+			}
+			for k, v := range evicted {
+				if v != k*10 || c.Contains(k) {
+					t.Fatalf("bad evicted: %v", evicted)
+				}
+			}
+			c.Remove(3)
+			if evicted[3] != 30 {
+				t.Fatalf("remove should call onEvict: %v", evicted)
+			}
+			c.Purge()
+			if len(evicted) != 3 || c.Len() != 0 {
+				t.Fatalf("purge should call onEvict: %v", evicted)
+			}
+		})
+	}
+}
+
+// probe admits or rejects every candidate, remembering the victim it was
+// offered.
+type probe struct {
+	admit  bool
+	victim int
+}
+
+func (p *probe) Admit(_, victim int) bool {
+	p.victim = victim
+	return p.admit
+}
+
+// clockState returns the hand and the counts of every slot of a Clock or
+// ClockSweep.
+func clockState(c cache.Cache[int, int]) string {
+	var hand *ring.Ring
+	var counts []int
+	switch c := c.(type) {
+	case *Clock[int, int]:
+		hand = c.hand
+		for _, e := range ringEntries[CEntry[int, int]](c.hand, c.size, false) {
+			counts = append(counts, e.refCount)
+		}
+	case *ClockSweep[int, int]:
+		hand = c.hand
+		for _, e := range ringEntries[CSEntry[int, int]](c.hand, c.size, false) {
+			counts = append(counts, e.refCount, e.useCount)
+		}
+	}
+	return fmt.Sprintf("%p %v", hand, counts)
+}
+
+func TestClocks_AdmissionProbe(t *testing.T) {
+	for _, name := range []string{"clock", "clock-sweep"} {
+		t.Run(name, func(t *testing.T) {
+			var evicted []int
+			c, err := clocks()[name](8, func(k, _ int) { evicted = append(evicted, k) })
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			p := new(probe)
+			c.(interface{ SetAdmission(cache.Admission[int]) }).SetAdmission(p)
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 5000; i++ {
+				key := r.Intn(24)
+				if r.Intn(3) == 0 {
+					c.Get(key)
+					continue
+				}
+				p.admit = r.Intn(2) == 0
+				if c.Len() < 8 || c.Contains(key) {
+					c.Add(key, key)
+					continue
+				}
+				if !p.admit {
+					// a rejected candidate leaves the clock as it was
+					before := clockState(c)
+					if c.Add(key, key) || c.Contains(key) {
+						t.Fatalf("%d should be rejected", key)
+					}
+					if after := clockState(c); after != before {
+						t.Fatalf("rejection changed the clock:\n%s\n%s", before, after)
+					}
+					continue
+				}
+				// an admitted one evicts exactly the victim it was offered
+				n := len(evicted)
+				c.Add(key, key)
+				if len(evicted) != n+1 || evicted[n] != p.victim {
+					t.Fatalf("offered %d, evicted %v", p.victim, evicted[n:])
+				}
+			}
+		})
+	}
+}
+
+func TestClocks_ReuseRemovedSlot(t *testing.T) {
+	for name, newClock := range clocks() {
+		t.Run(name, func(t *testing.T) {
+			c, err := newClock(3, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			for i := 1; i <= 4; i++ {
+				c.Add(i, i)
+			}
+			// the slot of a removed key is reused rather than evicting
+			var removed int
+			for _, k := range c.Keys(false) {
+				removed = k
+				break
+			}
+			c.Remove(removed)
+			if c.Add(5, 5) || c.Len() != 3 {
+				t.Fatalf("should not evict: %v", c.Keys(false))
+			}
+			if !c.Add(6, 6) || c.Len() != 3 {
+				t.Fatalf("should evict: %v", c.Keys(false))
+			}
+		})
+	}
+}
+
+func TestClocks_ResizeKeepsReferences(t *testing.T) {
+	for _, name := range []string{"clock", "clock-sweep"} {
+		t.Run(name, func(t *testing.T) {
+			c, err := clocks()[name](4, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			for i := 1; i <= 4; i++ {
+				c.Add(i, i)
+			}
+			c.Get(3)
+			if evicted, err := c.Resize(2); err != nil || evicted != 2 {
+				t.Fatalf("bad resize: %d %v", evicted, err)
+			}
+			if keys := c.Keys(false); len(keys) != 2 || keys[0] != 3 || keys[1] != 4 {
+				t.Fatalf("bad keys: %v", keys)
+			}
+			if values := c.Values(true); len(values) != 2 || values[0] != 4 || values[1] != 3 {
+				t.Fatalf("bad values: %v", values)
+			}
+			// 3 was referenced before the resize, so 4 goes first
+			c.Add(5, 5)
+			if !c.Contains(3) || c.Contains(4) {
+				t.Fatalf("bad keys: %v", c.Keys(false))
+			}
+			if evicted, _ := c.Resize(8); evicted != 0 || c.Len() != 2 {
+				t.Fatalf("bad grow: %d %d", evicted, c.Len())
+			}
+			for i := 10; i < 16; i++ {
+				if c.Add(i, i) {
+					t.Fatalf("should not evict below the new size")
+				}
+			}
+		})
+	}
+}
+
+func TestWSClock_Window(t *testing.T) {
+	now := time.Unix(0, 0)
+	c, err := NewWSClockParams[int, int](3, WSClockConfig[int, int]{
+		Window: 10 * time.Second,
+		Now:    func() time.Time { return now },
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		c.Add(i, i)
+	}
+	// every entry is in the working set, the oldest one is evicted
+	for i := 4; i <= 6; i++ {
+		if !c.Add(i, i) || c.Len() != 3 {
+			t.Fatalf("should evict: %v", c.Keys(false))
+		}
+	}
+	if keys := c.Keys(false); len(keys) != 3 || keys[0] != 4 || keys[1] != 5 || keys[2] != 6 {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	c.Purge()
+	for i := 1; i <= 4; i++ {
+		c.Add(i, i)
+	}
+	// 2 is referenced so it joins the working set again, 3 is too old
+	now = now.Add(20 * time.Second)
+	c.Get(2)
+	c.Add(5, 5)
+	if !c.Contains(2) || c.Contains(3) {
+		t.Fatalf("bad keys: %v", c.Keys(false))
+	}
+}
+
+func TestWSClock_WriteBack(t *testing.T) {
+	now := time.Unix(0, 0)
+	var written []int
+	c, err := NewWSClockParams[int, int](3, WSClockConfig[int, int]{
+		Window:    10 * time.Second,
+		Now:       func() time.Time { return now },
+		WriteBack: func(k, v int) { written = append(written, k) },
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.AddDirty(1, 1)
+	c.Add(2, 2)
+	c.Add(3, 3)
+	// 1 is evicted, so it is written back first
+	c.Add(4, 4)
+	if c.Contains(1) || len(written) != 1 || written[0] != 1 {
+		t.Fatalf("bad written: %v", written)
+	}
+
+	c.AddDirty(2, 2)
+	now = now.Add(20 * time.Second)
+	c.Add(5, 5)
+	if c.Contains(3) || len(written) != 1 {
+		t.Fatalf("clean 3 should be evicted: %v %v", c.Keys(false), written)
+	}
+
+	// the hand writes the old dirty 2 back and evicts it once clean
+	now = now.Add(20 * time.Second)
+	c.Add(6, 6)
+	if c.Contains(2) || len(written) != 2 || written[1] != 2 {
+		t.Fatalf("bad written: %v %v", c.Keys(false), written)
+	}
+
+	c.AddDirty(6, 6)
+	if n := c.Flush(); n != 1 || written[2] != 6 {
+		t.Fatalf("bad flush: %d %v", n, written)
+	}
+	if n := c.Flush(); n != 0 {
+		t.Fatalf("entries should be clean: %d", n)
+	}
+}
+
+func TestWSClock_MaxScan(t *testing.T) {
+	if _, err := NewWSClockParams[int, int](3, WSClockConfig[int, int]{Window: -1}, nil); err == nil {
+		t.Fatalf("should fail on negative window")
+	}
+	now := time.Unix(0, 0)
+	var written, evicted []int
+	c, err := NewWSClockParams[int, int](4, WSClockConfig[int, int]{
+		Window:    10 * time.Second,
+		Now:       func() time.Time { return now },
+		MaxScan:   2,
+		WriteBack: func(k, v int) { written = append(written, k) },
+	}, func(k, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		c.AddDirty(i, i)
+		now = now.Add(time.Second)
+	}
+
+	// the hand clears the reference bits of 1 and 2 only, then falls back
+	// to the oldest entry it saw, writing it back before evicting it
+	c.Add(5, 5)
+	if len(evicted) != 1 || evicted[0] != 1 || len(written) != 1 {
+		t.Fatalf("bad eviction: %v %v", evicted, written)
+	}
+
+	// 2 is old and written back as the hand passes it, 3 is still
+	// referenced as the first scan stopped before it
+	now = now.Add(20 * time.Second)
+	c.Add(6, 6)
+	if len(evicted) != 2 || evicted[1] != 2 || len(written) != 2 || written[1] != 2 {
+		t.Fatalf("bad eviction: %v %v", evicted, written)
+	}
+	if e := c.items[3].Value.(*WSEntry[int, int]); e.refCount != 0 || !e.dirty {
+		t.Fatalf("3 should have been inspected once: %+v", e)
+	}
+
+	// the victim offered to admission is the one evicted, even when the
+	// MaxScan fallback leaves the hand on an entry of the working set
+	c.Purge()
+	evicted = nil
+	for i := 1; i <= 4; i++ {
+		c.Add(i, i)
+	}
+	for k, age := range map[int]int{1: 95, 2: 92, 3: 91, 4: 99} {
+		entry := c.items[k].Value.(*WSEntry[int, int])
+		entry.refCount, entry.age = 0, time.Unix(int64(age), 0)
+	}
+	now = time.Unix(100, 0)
+	p := &probe{admit: true}
+	c.SetAdmission(p)
+	c.Add(5, 5)
+	if p.victim != 2 || len(evicted) != 1 || evicted[0] != 2 {
+		t.Fatalf("offered %d, evicted %v", p.victim, evicted)
+	}
+}
+
+func TestWSClock_AdmissionProbe(t *testing.T) {
+	now := time.Unix(0, 0)
+	var written, evicted []int
+	c, err := NewWSClockParams[int, int](4, WSClockConfig[int, int]{
+		Window:    10 * time.Second,
+		Now:       func() time.Time { return now },
+		WriteBack: func(k, v int) { written = append(written, k) },
+	}, func(k, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		c.AddDirty(i, i)
+	}
+	// 1 and 3 are dirty outside the working set, 2 is referenced and 4 is
+	// recent, so a sweep writes 1 and 3 back and falls back to the oldest
+	for k, ref := range map[int]int{1: 0, 2: 1, 3: 0, 4: 0} {
+		entry := c.items[k].Value.(*WSEntry[int, int])
+		entry.refCount, entry.dirty = ref, k != 4
+	}
+	c.items[4].Value.(*WSEntry[int, int]).age = time.Unix(95, 0)
+	now = time.Unix(100, 0)
+
+	state := func() string {
+		s := fmt.Sprint(c.hand.Value.(*WSEntry[int, int]).Key)
+		for _, k := range c.Keys(false) {
+			e := c.items[k].Value.(*WSEntry[int, int])
+			s += fmt.Sprintf(" %d:%d/%d/%v", k, e.refCount, e.age.Unix(), e.dirty)
+		}
+		return s
+	}
+	before := state()
+	p := &probe{}
+	c.SetAdmission(p)
+	if c.Add(5, 5) || c.Contains(5) || p.victim != 1 {
+		t.Fatalf("5 should have been rejected against 1, offered %d", p.victim)
+	}
+	if got := state(); got != before || len(written) != 0 {
+		t.Fatalf("rejection changed the clock: %s, want %s, written %v", got, before, written)
+	}
+
+	p.admit = true
+	if !c.Add(5, 5) || p.victim != 1 || fmt.Sprint(evicted) != "[1]" || fmt.Sprint(written) != "[1 3]" {
+		t.Fatalf("offered %d, evicted %v, written %v", p.victim, evicted, written)
+	}
+}
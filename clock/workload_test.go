@@ -0,0 +1,31 @@
+package clock
+
+import (
+	"fast-cache/cache"
+	"fast-cache/workload/workloadtest"
+	"testing"
+)
+
+func BenchmarkClock_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewClock[uint64, uint64](size, nil)
+	})
+}
+
+func BenchmarkClockSweep_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewClockSweep[uint64, uint64](size, nil)
+	})
+}
+
+func BenchmarkWSClock_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewWSClock[uint64, uint64](size, nil)
+	})
+}
+
+func BenchmarkClockPro_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewClockPro[uint64, uint64](size, nil)
+	})
+}
@@ -3,38 +3,92 @@ package clock
 import (
 	"container/ring"
 	"errors"
+	"fast-cache/cache"
 	"time"
 )
 
+// DefaultWSClockWindow is the working-set window of a WSClock built by
+// NewWSClock.
+const DefaultWSClockWindow = 5 * time.Second
+
 type WSEntry[K comparable, V any] struct {
 	Key      K
 	Val      V
 	refCount int
-	age      time.Time
+	age      time.Time // time of last use, recorded by the hand
+	dirty    bool
+}
+
+var _ cache.Cache[int, int] = (*WSClock[int, int])(nil)
+var _ cache.StatsProvider = (*WSClock[int, int])(nil)
+var _ cache.CapacityProvider = (*WSClock[int, int])(nil)
+
+// WSClockConfig configures a WSClock, the zero value of a field selects its
+// default.
+type WSClockConfig[K comparable, V any] struct {
+	// Window is the working-set window τ, an unreferenced entry not used
+	// for longer is outside the working set and can be evicted. Zero means
+	// DefaultWSClockWindow.
+	Window time.Duration
+
+	// Now is the time source, nil means time.Now.
+	Now func() time.Time
+
+	// MaxScan bounds the entries the hand inspects to find a victim, when
+	// none is found the oldest entry seen is evicted. Zero means the cache
+	// size, i.e. one turn of the clock.
+	MaxScan int
+
+	// WriteBack is called to write a dirty entry back, when the hand passes
+	// it outside the working set and before it is evicted.
+	WriteBack func(key K, value V)
 }
 
 type WSClock[K comparable, V any] struct {
-	size    int
-	items   map[K]*ring.Ring
-	limit   time.Duration
-	hand    *ring.Ring
-	head    *ring.Ring
-	onEvict EvictCallback[K, V]
+	size      int
+	items     map[K]*ring.Ring
+	window    time.Duration
+	now       func() time.Time
+	maxScan   int
+	writeBack func(key K, value V)
+	hand      *ring.Ring
+	head      *ring.Ring
+	onEvict   EvictCallback[K, V]
+	stats     *cache.StatsCounter
+	admit     cache.Admitter[K]
 }
 
 // NewWSClock constructs an Clock of the given size
 func NewWSClock[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*WSClock[K, V], error) {
+	return NewWSClockParams[K, V](size, WSClockConfig[K, V]{}, onEvict)
+}
+
+// NewWSClockParams constructs a WSClock of the given size configured by
+// config.
+func NewWSClockParams[K comparable, V any](size int, config WSClockConfig[K, V], onEvict EvictCallback[K, V]) (*WSClock[K, V], error) {
 	if size <= 0 {
 		return nil, errors.New("must provide a positive size")
 	}
+	if config.Window < 0 || config.MaxScan < 0 {
+		return nil, errors.New("invalid wsclock config")
+	}
+	if config.Window == 0 {
+		config.Window = DefaultWSClockWindow
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
 	r := ring.New(size)
 	c := &WSClock[K, V]{
-		size:    size,
-		hand:    r,
-		head:    r,
-		items:   make(map[K]*ring.Ring, size),
-		limit:   5 * time.Second,
-		onEvict: onEvict,
+		size:      size,
+		hand:      r,
+		head:      r,
+		items:     make(map[K]*ring.Ring, size),
+		window:    config.Window,
+		now:       config.Now,
+		maxScan:   config.MaxScan,
+		writeBack: config.WriteBack,
+		onEvict:   onEvict,
 	}
 	return c, nil
 }
@@ -43,92 +97,289 @@ func NewWSClock[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*WS
 //
 // If value satisfies "interface{ GetReferenceCount() int }", the value of
 // the GetReferenceCount() method is used to set the initial value of reference count.
-func (c *WSClock[K, V]) Add(key K, val V) {
+func (c *WSClock[K, V]) Add(key K, val V) (evicted bool) {
+	return c.add(key, val, false)
+}
+
+// AddDirty adds a value to the cache like Add and marks it dirty, so it is
+// written back before it is evicted. Returns true if an eviction occurred.
+func (c *WSClock[K, V]) AddDirty(key K, val V) (evicted bool) {
+	return c.add(key, val, true)
+}
+
+func (c *WSClock[K, V]) add(key K, val V, dirty bool) (evicted bool) {
+	c.admit.Record(key)
 	if e, ok := c.items[key]; ok {
 		entry := e.Value.(*WSEntry[K, V])
 		entry.refCount = 1
 		entry.Val = val
-		return
+		entry.dirty = entry.dirty || dirty
+		c.stats.Updated()
+		return false
+	}
+	now := c.now()
+	if c.admit.Enabled() && len(c.items) >= c.size {
+		// a rejected candidate must leave the clock as it was
+		if v := c.victim(now); v != nil && !c.admit.Admit(key, v.Value.(*WSEntry[K, V]).Key) {
+			return false
+		}
+	}
+	if len(c.items) < c.size {
+		c.hand = freeSlot(c.hand)
+	} else {
+		c.sweep(now)
+		evicted = c.evictHand()
 	}
-	c.evict()
 	c.hand.Value = &WSEntry[K, V]{
 		Key:      key,
 		Val:      val,
 		refCount: 1,
-		age:      time.Now(),
+		age:      now,
+		dirty:    dirty,
 	}
 	c.items[key] = c.hand
 	c.hand = c.hand.Next()
+	c.stats.Added()
+	return evicted
 }
 
-// Get looks up a key's value from the cache.
+// Get looks up a key's value from the cache, setting its reference bit.
 func (c *WSClock[K, V]) Get(key K) (value V, ok bool) {
+	c.admit.Record(key)
 	if ent, ok := c.items[key]; ok {
 		entry := ent.Value.(*WSEntry[K, V])
-		entry.age = time.Now()
+		entry.refCount = 1
+		c.stats.Hit()
 		return entry.Val, true
 	}
+	c.stats.Miss()
 	return
 }
 
-func (c *WSClock[K, V]) evict() {
-	r := c.hand
-	flag := true
-	for c.hand.Value != nil && flag {
-		if c.hand.Value.(*WSEntry[K, V]).refCount == 0 {
-			if c.hand.Value.(*WSEntry[K, V]).age.Add(c.limit).Before(time.Now()) {
-				break
+// Peek returns the key value (or undefined if not found) without updating
+// the usage of the key.
+func (c *WSClock[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value.(*WSEntry[K, V]).Val, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without updating the usage.
+func (c *WSClock[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// sweep advances the hand until it rests on an empty slot or on a clean
+// entry outside the working set. A referenced entry has its reference bit
+// cleared and its time of last use set to now, a dirty entry outside the
+// working set is written back and becomes clean. After MaxScan entries the
+// hand rests on the oldest entry it saw.
+func (c *WSClock[K, V]) sweep(now time.Time) {
+	var oldest *ring.Ring
+	for i := 0; i < c.scanLimit() && c.hand.Value != nil; i++ {
+		entry := c.hand.Value.(*WSEntry[K, V])
+		if entry.refCount > 0 {
+			entry.refCount = 0
+			entry.age = now
+		} else if now.Sub(entry.age) > c.window {
+			if !entry.dirty {
+				return
 			}
-		} else {
-			// avoid infinite loop
-			c.hand.Value.(*WSEntry[K, V]).refCount = 0
-			c.hand = c.hand.Next()
+			c.clean(entry)
 		}
-		if c.hand == r {
-			flag = false
+		if oldest == nil || entry.age.Before(oldest.Value.(*WSEntry[K, V]).age) {
+			oldest = c.hand
 		}
+		c.hand = c.hand.Next()
+	}
+	if c.hand.Value != nil && oldest != nil {
+		c.hand = oldest
 	}
+}
+
+// victim returns the slot a sweep at now stops at without moving the hand,
+// clearing reference bits, setting ages or writing entries back. A
+// referenced entry counts as used at now and a dirty entry outside the
+// working set as clean once the hand has passed it.
+func (c *WSClock[K, V]) victim(now time.Time) *ring.Ring {
+	var oldest *ring.Ring
+	var oldestAge time.Time
+	r := c.hand
+	for i := 0; i < c.scanLimit() && r.Value != nil; i, r = i+1, r.Next() {
+		entry := r.Value.(*WSEntry[K, V])
+		age := entry.age
+		if entry.refCount > 0 {
+			age = now
+		} else if now.Sub(age) > c.window && !entry.dirty {
+			return r
+		}
+		if oldest == nil || age.Before(oldestAge) {
+			oldest, oldestAge = r, age
+		}
+	}
+	if r.Value == nil {
+		// the sweep stops at an empty slot
+		return nil
+	}
+	return oldest
+}
+
+// scanLimit returns the number of entries a sweep inspects at most.
+func (c *WSClock[K, V]) scanLimit() int {
+	if c.maxScan == 0 {
+		return c.size
+	}
+	return c.maxScan
+}
+
+// clean writes entry back if it is dirty.
+func (c *WSClock[K, V]) clean(entry *WSEntry[K, V]) {
+	if !entry.dirty {
+		return
+	}
+	entry.dirty = false
+	if c.writeBack != nil {
+		c.writeBack(entry.Key, entry.Val)
+	}
+}
+
+// evict removes the first evictable entry found by the hand, writing it
+// back first if it is dirty. Returns true if an entry was evicted.
+func (c *WSClock[K, V]) evict() bool {
+	c.sweep(c.now())
+	return c.evictHand()
+}
+
+// evictHand removes the entry under the hand, writing it back first if it
+// is dirty. Returns true if an entry was evicted.
+func (c *WSClock[K, V]) evictHand() bool {
 	if c.hand.Value != nil {
 		entry := c.hand.Value.(*WSEntry[K, V])
+		c.clean(entry)
 		delete(c.items, entry.Key)
 		c.hand.Value = nil
+		c.stats.Evicted(cache.EvictReasonCapacity, 1)
+		if c.onEvict != nil {
+			c.onEvict(entry.Key, entry.Val)
+		}
+		return true
 	}
+	return false
+}
 
+// Flush writes every dirty entry back, returning how many were written.
+func (c *WSClock[K, V]) Flush() (written int) {
+	for _, e := range c.items {
+		if entry := e.Value.(*WSEntry[K, V]); entry.dirty {
+			c.clean(entry)
+			written++
+		}
+	}
+	return written
 }
 
 // Keys returns the keys of the cache. the order as same as current ring order.
-func (c *WSClock[K, V]) Keys() []K {
-	keys := make([]K, 0, len(c.items))
-	r := c.head
-	if r.Value == nil {
-		return []K{}
+func (c *WSClock[K, V]) Keys(reverse bool) []K {
+	entries := ringEntries[WSEntry[K, V]](c.head, len(c.items), reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
 	}
-	// the first element
-	keys = append(keys, r.Value.(*WSEntry[K, V]).Key)
+	return keys
+}
 
-	// iterating
-	for p := c.head.Next(); p != r; p = p.Next() {
-		if p.Value == nil {
-			continue
-		}
-		e := p.Value.(*CSEntry[K, V])
-		keys = append(keys, e.Key)
+// Values returns the values of the cache. the order as same as Keys.
+func (c *WSClock[K, V]) Values(reverse bool) []V {
+	entries := ringEntries[WSEntry[K, V]](c.head, len(c.items), reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Val
 	}
-	return keys
+	return values
 }
 
-// Delete deletes the item with provided key from the cache.
-func (c *WSClock[K, V]) Delete(key K) {
+// Remove removes the provided key from the cache, returning if the
+// key was contained. A dirty entry is dropped without being written back.
+func (c *WSClock[K, V]) Remove(key K) (present bool) {
 	if e, ok := c.items[key]; ok {
+		entry := e.Value.(*WSEntry[K, V])
 		delete(c.items, key)
 		e.Value = nil
+		c.stats.Evicted(cache.EvictReasonRemoved, 1)
 		if c.onEvict != nil {
-			c.onEvict(e.Value.(*WSEntry[K, V]).Key, e.Value.(*WSEntry[K, V]).Val)
+			c.onEvict(entry.Key, entry.Val)
 		}
+		return true
 	}
+	return false
+}
+
+// Delete deletes the item with provided key from the cache.
+//
+// Deprecated: use Remove.
+func (c *WSClock[K, V]) Delete(key K) {
+	c.Remove(key)
 }
 
 // Len returns the number of items in the cache.
 func (c *WSClock[K, V]) Len() int {
 	return len(c.items)
 }
+
+// Cap returns the size of the cache.
+func (c *WSClock[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache, dirty entries are dropped
+// without being written back, call Flush first to keep them.
+func (c *WSClock[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, e := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, e.Value.(*WSEntry[K, V]).Val)
+		}
+		delete(c.items, k)
+	}
+	r := ring.New(c.size)
+	c.head, c.hand = r, r
+}
+
+// Resize changes the cache size. Entries keep their usage and ring order,
+// starting from the current hand.
+func (c *WSClock[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	for len(c.items) > size {
+		if c.evict() {
+			evicted++
+		} else {
+			c.hand = c.hand.Next()
+		}
+	}
+	c.head, c.hand = resizeRing(c.hand, size, c.items)
+	c.size = size
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *WSClock[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *WSClock[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// SetAdmission installs the admission policy consulted before a new key
+// evicts an entry, nil admits every key.
+func (c *WSClock[K, V]) SetAdmission(admission cache.Admission[K]) {
+	c.admit.Set(admission)
+}
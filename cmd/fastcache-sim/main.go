@@ -0,0 +1,343 @@
+// Command fastcache-sim replays a key access trace through every cache
+// policy of the repository at a sweep of sizes, and prints their hit ratios,
+// also as a fraction of those of the optimal offline policy, as tables and
+// optionally as CSV for plotting.
+//
+// Usage:
+//
+//	fastcache-sim [flags] trace
+//	fastcache-sim [flags] -workload name
+//
+// The trace is read from standard input when its path is "-". With
+// -workload a seeded synthetic workload is replayed instead. Every access
+// is a Get, and a missed key is added to the cache. With -mrc the LRU
+// miss-ratio curve is estimated from stack distances in one pass, sampling
+// keys for large traces, instead of replaying the policies.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// defaultFractions are the cache sizes simulated by default, as fractions
+// of the number of unique keys of the trace.
+var defaultFractions = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.2, 0.5}
+
+// notAvailable is written in place of the results of a policy that could
+// not run at a size.
+const notAvailable = "n/a"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "fastcache-sim:", err)
+		os.Exit(1)
+	}
+}
+
+// result is the outcome of replaying the trace through one policy at one
+// size.
+type result struct {
+	policy     string
+	size       int
+	hits       int
+	err        error // the policy could not run at this size
+	optimal    int   // hits of the optimal policy at the same size
+	optimalErr error // the optimal policy could not run at this size
+}
+
+// hitRatio returns the hits as a fraction of the accesses, ok is false if
+// the policy could not run.
+func (r result) hitRatio(accesses int) (ratio float64, ok bool) {
+	if r.err != nil {
+		return 0, false
+	}
+	return float64(r.hits) / float64(accesses), true
+}
+
+// ofOptimal returns the hits as a fraction of the optimal ones, ok is false
+// if either policy could not run.
+func (r result) ofOptimal() (ratio float64, ok bool) {
+	if r.err != nil || r.optimalErr != nil {
+		return 0, false
+	}
+	if r.optimal == 0 {
+		// no policy can hit
+		return 1, true
+	}
+	return float64(r.hits) / float64(r.optimal), true
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("fastcache-sim", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	var (
+		format    = fs.String("format", formatAuto, "trace format: auto, plain, csv, arc or lirs")
+		csvKey    = fs.Int("csv-key", 1, "column of the key in a csv trace, counting from 0")
+		csvHeader = fs.Bool("csv-header", false, "skip the first record of a csv trace")
+		sizesFlag = fs.String("sizes", "", "comma separated cache sizes, defaults to fractions of the unique keys")
+		names     = fs.String("policies", "", "comma separated policies to simulate, defaults to all")
+		csvOut    = fs.String("csv", "", "also write the results as csv to this file, - for standard output")
+		curve     = fs.Bool("mrc", false, "estimate the lru miss-ratio curve in one pass instead of replaying the policies")
+		sample    = fs.Float64("sample", 1, "fraction of the keys sampled by -mrc")
+	)
+	var wl workloadOptions
+	fs.StringVar(&wl.name, "workload", "", "replay a synthetic workload instead of a trace: uniform, zipf, loop, scan-mix, shifting, two-pool or hot-cold")
+	fs.IntVar(&wl.accesses, "accesses", 1000000, "accesses of the workload")
+	fs.Uint64Var(&wl.keys, "keys", 100000, "keys of the workload, the scan length of scan-mix and the large pool of two-pool")
+	fs.Uint64Var(&wl.hot, "hot", 10000, "hot keys of the scan-mix, shifting and hot-cold workloads, the small pool of two-pool")
+	fs.Float64Var(&wl.hotRatio, "hot-ratio", 0.8, "share of the accesses going to the hot keys")
+	fs.Float64Var(&wl.skew, "skew", 0.99, "skew of the zipf workload")
+	fs.IntVar(&wl.period, "period", 100000, "accesses before the hot keys of the shifting workload move")
+	fs.Int64Var(&wl.seed, "seed", 1, "seed of the workload")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcache-sim [flags] trace")
+		fmt.Fprintln(fs.Output(), "       fastcache-sim [flags] -workload name")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		source string
+		keys   []uint64
+		unique int
+		err    error
+	)
+	if wl.name != "" {
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return errors.New("unexpected trace with a workload")
+		}
+		source = "workload " + wl.name
+		if keys, unique, err = generate(wl); err != nil {
+			return err
+		}
+	} else {
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return errors.New("expected one trace")
+		}
+		path := fs.Arg(0)
+		source = "trace " + path
+		opts := traceOptions{format: *format, csvKey: *csvKey, csvHeader: *csvHeader}
+		if opts.format == formatAuto {
+			opts.format = detectFormat(path)
+		}
+		r := stdin
+		if path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		if keys, unique, err = readTrace(r, opts); err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return errors.New("empty trace")
+		}
+	}
+
+	sizes, err := parseSizes(*sizesFlag, unique)
+	if err != nil {
+		return err
+	}
+	if *curve {
+		c, err := analyze(keys, *sample)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%s: %d accesses, %d unique keys\n\n", source, len(keys), unique)
+		fmt.Fprintf(stdout, "lru miss-ratio curve, sample rate %g, flat past size %d\n\n", *sample, c.MaxSize())
+		if err := writeCurve(stdout, c, sizes); err != nil {
+			return err
+		}
+		return writeCSVFile(*csvOut, stdout, func(w io.Writer) error {
+			return writeCurveCSV(w, c, sizes)
+		})
+	}
+	selected, err := selectPolicies(*names)
+	if err != nil {
+		return err
+	}
+
+	// a policy failing at a size, as some do at the smallest ones, is
+	// reported as n/a instead of aborting the run
+	optimalHits := make(map[int]int, len(sizes))
+	optimalErrs := make(map[int]error, len(sizes))
+	for _, size := range sizes {
+		optimalHits[size], optimalErrs[size] = simulate(optimal, size, keys)
+	}
+	var results []result
+	for _, p := range selected {
+		for _, size := range sizes {
+			r := result{policy: p.name, size: size, optimal: optimalHits[size], optimalErr: optimalErrs[size]}
+			if p.name == optimal.name {
+				r.hits, r.err = r.optimal, r.optimalErr
+			} else {
+				r.hits, r.err = simulate(p, size, keys)
+			}
+			results = append(results, r)
+		}
+	}
+
+	fmt.Fprintf(stdout, "%s: %d accesses, %d unique keys\n\n", source, len(keys), unique)
+	if err := writeTable(stdout, results, sizes, func(r result) (float64, bool) {
+		return r.hitRatio(len(keys))
+	}); err != nil {
+		return err
+	}
+	writeFailures(stdout, results)
+	fmt.Fprintf(stdout, "\nhit ratio as a fraction of optimal (%s)\n\n", optimal.name)
+	if err := writeTable(stdout, results, sizes, result.ofOptimal); err != nil {
+		return err
+	}
+	return writeCSVFile(*csvOut, stdout, func(w io.Writer) error {
+		return writeCSV(w, results, len(keys))
+	})
+}
+
+// writeCSVFile writes csv output with write to path, to stdout after a
+// blank line if it is "-", and nowhere if it is empty.
+func writeCSVFile(path string, stdout io.Writer, write func(io.Writer) error) error {
+	switch path {
+	case "":
+		return nil
+	case "-":
+		fmt.Fprintln(stdout)
+		return write(stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseSizes parses the comma separated sizes, or derives the default ones
+// from the number of unique keys.
+func parseSizes(s string, unique int) ([]int, error) {
+	var sizes []int
+	if s == "" {
+		for _, f := range defaultFractions {
+			sizes = append(sizes, max(1, int(f*float64(unique))))
+		}
+	} else {
+		for _, field := range strings.Split(s, ",") {
+			size, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || size <= 0 {
+				return nil, fmt.Errorf("invalid size %q", field)
+			}
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Ints(sizes)
+	// drop duplicates
+	n := 0
+	for i, size := range sizes {
+		if i == 0 || size != sizes[n-1] {
+			sizes[n] = size
+			n++
+		}
+	}
+	return sizes[:n], nil
+}
+
+// selectPolicies returns the named policies, or all of them.
+func selectPolicies(s string) ([]policy, error) {
+	if s == "" {
+		return policies, nil
+	}
+	var selected []policy
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		i := 0
+		for i < len(policies) && policies[i].name != name {
+			i++
+		}
+		if i == len(policies) {
+			return nil, fmt.Errorf("unknown policy %q", name)
+		}
+		selected = append(selected, policies[i])
+	}
+	return selected, nil
+}
+
+// writeTable writes the ratio of every result as a table, one row per
+// policy and one column per size, n/a where ratio is not ok.
+func writeTable(w io.Writer, results []result, sizes []int, ratio func(result) (float64, bool)) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "policy\t")
+	for _, size := range sizes {
+		fmt.Fprintf(tw, "%d\t", size)
+	}
+	fmt.Fprintln(tw)
+	for i, r := range results {
+		if i%len(sizes) == 0 {
+			fmt.Fprintf(tw, "%s\t", r.policy)
+		}
+		if v, ok := ratio(r); ok {
+			fmt.Fprintf(tw, "%.2f%%\t", 100*v)
+		} else {
+			fmt.Fprint(tw, notAvailable+"\t")
+		}
+		if i%len(sizes) == len(sizes)-1 {
+			fmt.Fprintln(tw)
+		}
+	}
+	return tw.Flush()
+}
+
+// writeFailures writes why the n/a results could not run, after a blank
+// line.
+func writeFailures(w io.Writer, results []result) {
+	first := true
+	for _, r := range results {
+		if r.err != nil {
+			if first {
+				fmt.Fprintln(w)
+				first = false
+			}
+			fmt.Fprintf(w, "%s: %s at size %d: %v\n", notAvailable, r.policy, r.size, r.err)
+		}
+	}
+}
+
+// writeCSV writes one record per policy and size, with n/a fields for
+// the values that could not be computed.
+func writeCSV(w io.Writer, results []result, accesses int) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"policy", "size", "accesses", "hits", "hit_ratio", "optimal_hits", "of_optimal"})
+	for _, r := range results {
+		hits, ratio := notAvailable, notAvailable
+		if v, ok := r.hitRatio(accesses); ok {
+			hits, ratio = strconv.Itoa(r.hits), strconv.FormatFloat(v, 'f', 6, 64)
+		}
+		optimalHits, ofOptimal := notAvailable, notAvailable
+		if r.optimalErr == nil {
+			optimalHits = strconv.Itoa(r.optimal)
+		}
+		if v, ok := r.ofOptimal(); ok {
+			ofOptimal = strconv.FormatFloat(v, 'f', 6, 64)
+		}
+		cw.Write([]string{r.policy, strconv.Itoa(r.size), strconv.Itoa(accesses), hits, ratio, optimalHits, ofOptimal})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadTrace(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   traceOptions
+		trace  string
+		keys   []uint64
+		unique int
+	}{
+		{"plain", traceOptions{format: formatPlain}, "a\n# comment\nb x\n\na\n", []uint64{0, 1, 0}, 2},
+		{"lirs", traceOptions{format: formatLIRS}, "7\n*\n8\n7\n", []uint64{0, 1, 0}, 2},
+		{"arc", traceOptions{format: formatARC}, "10 3 0 0\n11 1 0 1\n", []uint64{0, 1, 2, 1}, 3},
+		{"csv", traceOptions{format: formatCSV, csvKey: 1, csvHeader: true}, "ts,key,size\n1,a,10\n2, b,20\n3,a,10\n", []uint64{0, 1, 0}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, unique, err := readTrace(strings.NewReader(tt.trace), tt.opts)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if !reflect.DeepEqual(keys, tt.keys) || unique != tt.unique {
+				t.Fatalf("got %v %d, want %v %d", keys, unique, tt.keys, tt.unique)
+			}
+		})
+	}
+
+	if _, _, err := readTrace(strings.NewReader("10 x\n"), traceOptions{format: formatARC}); err == nil {
+		t.Fatalf("should fail on a bad arc record")
+	}
+	if _, _, err := readTrace(strings.NewReader("1,a\n"), traceOptions{format: formatCSV, csvKey: 3}); err == nil {
+		t.Fatalf("should fail on a missing csv column")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	for path, want := range map[string]string{
+		"P1.lis":   formatARC,
+		"cpp.trc":  formatLIRS,
+		"web.CSV":  formatCSV,
+		"keys.txt": formatPlain,
+	} {
+		if got := detectFormat(path); got != want {
+			t.Errorf("detectFormat(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := parseSizes("30, 10,30", 0)
+	if err != nil || !reflect.DeepEqual(sizes, []int{10, 30}) {
+		t.Fatalf("got %v %v", sizes, err)
+	}
+	sizes, err = parseSizes("", 1000)
+	if err != nil || !reflect.DeepEqual(sizes, []int{1, 5, 10, 50, 100, 200, 500}) {
+		t.Fatalf("got %v %v", sizes, err)
+	}
+	if _, err := parseSizes("10,0", 0); err == nil {
+		t.Fatalf("should fail on size 0")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	trace := filepath.Join(dir, "trace.txt")
+	// a loop over 4 keys, a cache of 4 hits every access after the first pass
+	var b strings.Builder
+	for i := 0; i < 25; i++ {
+		for _, k := range []string{"a", "b", "c", "d"} {
+			b.WriteString(k + "\n")
+		}
+	}
+	if err := os.WriteFile(trace, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	csvPath := filepath.Join(dir, "out.csv")
+
+	var out bytes.Buffer
+	err := run([]string{"-sizes", "4", "-policies", "lru,fifo", "-csv", csvPath, trace}, nil, &out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(out.String(), "100 accesses, 4 unique keys") || !strings.Contains(out.String(), "96.00%") ||
+		!strings.Contains(out.String(), "fraction of optimal (belady)") {
+		t.Fatalf("bad output:\n%s", out.String())
+	}
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "policy,size,accesses,hits,hit_ratio,optimal_hits,of_optimal\n" +
+		"lru,4,100,96,0.960000,96,1.000000\nfifo,4,100,96,0.960000,96,1.000000\n"
+	if string(data) != want {
+		t.Fatalf("bad csv:\n%s", data)
+	}
+
+	if err := run([]string{"-policies", "nope", trace}, nil, &out); err == nil {
+		t.Fatalf("should fail on an unknown policy")
+	}
+}
+
+func TestRunSmallTrace(t *testing.T) {
+	trace := filepath.Join(t.TempDir(), "trace.txt")
+	var b strings.Builder
+	for i := 0; i < 600; i++ {
+		fmt.Fprintf(&b, "%d\n", i*7%150)
+	}
+	if err := os.WriteFile(trace, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the default sizes of 150 keys start at 1, too small for some
+	// policies, which are reported as n/a instead of failing the run
+	var out bytes.Buffer
+	if err := run([]string{"-csv", "-", trace}, nil, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(out.String(), "n/a: 2q at size 1:") || !strings.Contains(out.String(), "n/a: clock-pro at size 1:") {
+		t.Fatalf("bad output:\n%s", out.String())
+	}
+	for _, want := range []string{"\n2q,1,600,n/a,n/a,3,n/a\n", "\nclock-pro,1,600,n/a,n/a,3,n/a\n", "\nlru,1,600,0,0.000000,3,0.000000\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	keys := make([]uint64, 1000)
+	for i := range keys {
+		keys[i] = uint64(i % 10)
+	}
+	for _, p := range policies {
+		hits, err := simulate(p, 10, keys)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if hits != 990 {
+			t.Errorf("%s: %d hits, want 990", p.name, hits)
+		}
+	}
+}
+
+func TestRunWorkload(t *testing.T) {
+	var out bytes.Buffer
+	args := []string{"-workload", "loop", "-keys", "8", "-accesses", "80", "-sizes", "4,8", "-policies", "lru", "-csv", "-"}
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// a loop over 8 keys never hits a cache of 4, and always after the first
+	// pass in a cache of 8
+	want := "lru,4,80,0,0.000000,36,0.000000\nlru,8,80,72,0.900000,72,1.000000\n"
+	if !strings.Contains(out.String(), "workload loop: 80 accesses, 8 unique keys") || !strings.HasSuffix(out.String(), want) {
+		t.Fatalf("bad output:\n%s", out.String())
+	}
+
+	for _, args := range [][]string{
+		{"-workload", "nope"},
+		{"-workload", "zipf", "-keys", "0"},
+		{"-workload", "loop", "-accesses", "0"},
+		{"-workload", "loop", "trace.txt"},
+	} {
+		if err := run(args, nil, &out); err == nil {
+			t.Fatalf("%v should fail", args)
+		}
+	}
+}
+
+func TestRunCurve(t *testing.T) {
+	var out bytes.Buffer
+	args := []string{"-workload", "loop", "-keys", "8", "-accesses", "80", "-sizes", "4,8", "-mrc", "-csv", "-"}
+	if err := run(args, nil, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// the same as replaying an lru
+	want := "size,miss_ratio\n4,1.000000\n8,0.100000\n"
+	if !strings.Contains(out.String(), "flat past size 8") || !strings.HasSuffix(out.String(), want) {
+		t.Fatalf("bad output:\n%s", out.String())
+	}
+	if err := run([]string{"-workload", "loop", "-mrc", "-sample", "0"}, nil, &out); err == nil {
+		t.Fatalf("should fail on an invalid sample rate")
+	}
+}
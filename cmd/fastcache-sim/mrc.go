@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/csv"
+	"fast-cache/mrc"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// analyze computes the LRU miss-ratio curve of keys, sampling the given
+// fraction of the keys.
+func analyze(keys []uint64, rate float64) (*mrc.Curve, error) {
+	a, err := mrc.NewSampled[uint64](rate)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		a.Access(k)
+	}
+	return a.Curve(), nil
+}
+
+// writeCurve writes the miss ratio of the curve at every size as a table.
+func writeCurve(w io.Writer, curve *mrc.Curve, sizes []int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "size\tmiss ratio\thit ratio\t")
+	for _, size := range sizes {
+		m := curve.MissRatio(size)
+		fmt.Fprintf(tw, "%d\t%.2f%%\t%.2f%%\t\n", size, 100*m, 100*(1-m))
+	}
+	return tw.Flush()
+}
+
+// writeCurveCSV writes one record per size.
+func writeCurveCSV(w io.Writer, curve *mrc.Curve, sizes []int) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"size", "miss_ratio"})
+	for _, size := range sizes {
+		cw.Write([]string{
+			strconv.Itoa(size),
+			strconv.FormatFloat(curve.MissRatio(size), 'f', 6, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
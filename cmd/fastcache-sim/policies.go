@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fast-cache/belady"
+	"fast-cache/cache"
+	"fast-cache/clock"
+	"fast-cache/fifo"
+	"fast-cache/lfu"
+	"fast-cache/lru"
+	"fast-cache/tinylfu"
+	"fmt"
+	"time"
+)
+
+// logicalClock is the time source of the simulated policies, it ticks once
+// per access so time based policies do not depend on the replay speed.
+type logicalClock struct {
+	now int64
+}
+
+func (c *logicalClock) Now() time.Time { return time.Unix(0, c.now) }
+
+func (c *logicalClock) tick() { c.now++ }
+
+// policy constructs a cache of a replacement policy for the simulator.
+type policy struct {
+	name string
+	new  func(size int, clk *logicalClock, keys []uint64) (cache.Cache[uint64, struct{}], error)
+}
+
+// policies lists every policy the simulator replays, in table order.
+var policies = []policy{
+	{"lru", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return lru.New[uint64, struct{}](size)
+	}},
+	{"2q", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return lru.New2Q[uint64, struct{}](size)
+	}},
+	{"arc", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return lru.NewARC[uint64, struct{}](size)
+	}},
+	{"slru", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return lru.NewSLRU[uint64, struct{}](size, lru.DefaultSLRUProtectedRatio)
+	}},
+	{"lru-k", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return lru.NewLruK[uint64, struct{}](size, 2)
+	}},
+	{"lfu", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return lfu.NewLFU[uint64, struct{}](size, nil)
+	}},
+	{"lfu-bucket", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return lfu.NewBucketLFU[uint64, struct{}](size, nil)
+	}},
+	{"tinylfu", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return tinylfu.New[uint64, struct{}](size, nil)
+	}},
+	{"fifo", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return fifo.NewFIFO[uint64, struct{}](size, nil)
+	}},
+	{"s3fifo", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return fifo.NewS3FIFO[uint64, struct{}](size, nil)
+	}},
+	{"sieve", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return fifo.NewSieve[uint64, struct{}](size, nil)
+	}},
+	{"clock", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return clock.NewClock[uint64, struct{}](size, nil)
+	}},
+	{"clock-sweep", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return clock.NewClockSweep[uint64, struct{}](size, nil)
+	}},
+	{"wsclock", func(size int, clk *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		// an entry not used during the last size accesses leaves the
+		// working set
+		return clock.NewWSClockParams[uint64, struct{}](size, clock.WSClockConfig[uint64, struct{}]{
+			Window: time.Duration(size),
+			Now:    clk.Now,
+		}, nil)
+	}},
+	{"clock-pro", func(size int, _ *logicalClock, _ []uint64) (cache.Cache[uint64, struct{}], error) {
+		return clock.NewClockPro[uint64, struct{}](size, nil)
+	}},
+	optimal,
+}
+
+// optimal is the offline policy knowing the whole trace, its hits bound
+// those of every other policy.
+var optimal = policy{"belady", func(size int, _ *logicalClock, keys []uint64) (cache.Cache[uint64, struct{}], error) {
+	return belady.New[uint64, struct{}](size, keys, nil)
+}}
+
+// simulate replays keys through a cache of policy p of the given size and
+// returns the number of hits.
+func simulate(p policy, size int, keys []uint64) (hits int, err error) {
+	clk := &logicalClock{}
+	c, err := p.new(size, clk, keys)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", p.name, err)
+	}
+	return replay(c, clk, keys), nil
+}
+
+// replay runs keys through c, adding every missed key, and returns the
+// number of hits.
+func replay(c cache.Cache[uint64, struct{}], clk *logicalClock, keys []uint64) (hits int) {
+	for _, k := range keys {
+		clk.tick()
+		if _, ok := c.Get(k); ok {
+			hits++
+			continue
+		}
+		c.Add(k, struct{}{})
+	}
+	return hits
+}
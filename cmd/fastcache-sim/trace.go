@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Trace formats understood by readTrace.
+const (
+	formatAuto  = "auto"
+	formatPlain = "plain" // one key per line
+	formatCSV   = "csv"   // e.g. timestamp,key,size
+	formatARC   = "arc"   // start block, block count, ignored, request number
+	formatLIRS  = "lirs"  // one block number per line
+)
+
+// traceOptions configures how readTrace parses a trace.
+type traceOptions struct {
+	format    string
+	csvKey    int  // column of the key in a CSV trace
+	csvHeader bool // skip the first record of a CSV trace
+}
+
+// detectFormat guesses the format of a trace from its file extension.
+func detectFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return formatCSV
+	case ".lis":
+		return formatARC
+	case ".trc":
+		return formatLIRS
+	}
+	return formatPlain
+}
+
+// interner maps keys to dense ids, so every policy replays integers.
+type interner map[string]uint64
+
+func (in interner) id(key string) uint64 {
+	id, ok := in[key]
+	if !ok {
+		id = uint64(len(in))
+		in[key] = id
+	}
+	return id
+}
+
+// readTrace reads the accesses of a trace as key ids, and returns the number
+// of unique keys.
+func readTrace(r io.Reader, opts traceOptions) (keys []uint64, unique int, err error) {
+	in := make(interner)
+	switch opts.format {
+	case formatPlain:
+		keys, err = readLines(r, func(line string, emit func(string)) error {
+			emit(strings.Fields(line)[0])
+			return nil
+		}, in)
+	case formatLIRS:
+		keys, err = readLines(r, func(line string, emit func(string)) error {
+			// non numeric lines such as "*" separate trace segments
+			if _, err := strconv.ParseUint(line, 10, 64); err == nil {
+				emit(line)
+			}
+			return nil
+		}, in)
+	case formatARC:
+		keys, err = readLines(r, func(line string, emit func(string)) error {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return fmt.Errorf("bad arc record %q", line)
+			}
+			start, err := strconv.ParseUint(fields[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad arc start block %q", fields[0])
+			}
+			n, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad arc block count %q", fields[1])
+			}
+			for b := start; b < start+n; b++ {
+				emit(strconv.FormatUint(b, 10))
+			}
+			return nil
+		}, in)
+	case formatCSV:
+		keys, err = readCSV(r, opts, in)
+	default:
+		return nil, 0, fmt.Errorf("unknown trace format %q", opts.format)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return keys, len(in), nil
+}
+
+// readLines calls parse on every non-empty line of r that is not a comment,
+// interning the keys it emits.
+func readLines(r io.Reader, parse func(line string, emit func(string)) error, in interner) ([]uint64, error) {
+	var keys []uint64
+	emit := func(key string) { keys = append(keys, in.id(key)) }
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := parse(line, emit); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return keys, s.Err()
+}
+
+// readCSV reads the key column of every record of a CSV trace. Timestamps
+// and sizes are ignored, the policies are bounded by their entry count.
+func readCSV(r io.Reader, opts traceOptions, in interner) ([]uint64, error) {
+	if opts.csvKey < 0 {
+		return nil, errors.New("invalid csv key column")
+	}
+	var keys []uint64
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	for n := 0; ; n++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 && opts.csvHeader {
+			continue
+		}
+		if opts.csvKey >= len(rec) {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d: no column %d", line, opts.csvKey)
+		}
+		keys = append(keys, in.id(rec[opts.csvKey]))
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fast-cache/workload"
+	"fmt"
+)
+
+// workloadOptions configures a synthetic workload replayed instead of a
+// trace.
+type workloadOptions struct {
+	name     string
+	accesses int
+	keys     uint64
+	hot      uint64
+	hotRatio float64
+	skew     float64
+	period   int
+	seed     int64
+}
+
+// newGenerator returns the generator of the named workload.
+func newGenerator(opts workloadOptions) (workload.Generator, error) {
+	switch opts.name {
+	case "uniform":
+		return workload.NewUniform(opts.seed, opts.keys)
+	case "zipf":
+		return workload.NewZipf(opts.seed, opts.skew, opts.keys)
+	case "loop":
+		return workload.NewLoop(opts.keys)
+	case "scan-mix":
+		return workload.NewScanMix(opts.seed, opts.hot, opts.keys, opts.hotRatio)
+	case "shifting":
+		return workload.NewShifting(opts.seed, opts.keys, opts.hot, opts.hotRatio, opts.period)
+	case "two-pool":
+		return workload.NewTwoPool(opts.seed, opts.hot, opts.keys)
+	case "hot-cold":
+		if opts.keys == 0 {
+			return nil, errors.New("must provide a positive number of keys")
+		}
+		return workload.NewHotCold(opts.seed, opts.keys, opts.hotRatio, float64(opts.hot)/float64(opts.keys))
+	}
+	return nil, fmt.Errorf("unknown workload %q", opts.name)
+}
+
+// generate returns the accesses of the workload and the number of unique
+// keys among them.
+func generate(opts workloadOptions) (keys []uint64, unique int, err error) {
+	if opts.accesses <= 0 {
+		return nil, 0, errors.New("must provide a positive number of accesses")
+	}
+	g, err := newGenerator(opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", opts.name, err)
+	}
+	keys = workload.Keys(g, opts.accesses)
+	seen := make(map[uint64]struct{})
+	for _, k := range keys {
+		seen[k] = struct{}{}
+	}
+	return keys, len(seen), nil
+}
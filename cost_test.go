@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fast-cache/lru"
+	"fmt"
+	"testing"
+)
+
+func TestLRUWithCost(t *testing.T) {
+	var evicted []string
+	l, err := lru.NewLRUWithCost[string, string](10, func(_ string, v string) int64 {
+		return int64(len(v))
+	}, func(k string, _ string) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add("a", "aaaa")
+	l.Add("b", "bbbb")
+	if got := l.Cost(); got != 8 {
+		t.Fatalf("Cost() = %d", got)
+	}
+	l.Get("a")
+	if !l.Add("c", "cccc") {
+		t.Fatalf("Add should evict")
+	}
+	if l.Contains("b") || !l.Contains("a") || l.Cost() != 8 {
+		t.Fatalf("bad eviction: %v, cost %d", l.Keys(false), l.Cost())
+	}
+
+	// growing a value evicts older entries until it fits
+	if !l.Add("a", "aaaaaaaa") {
+		t.Fatalf("update should evict")
+	}
+	if l.Contains("c") || l.Cost() != 8 {
+		t.Fatalf("bad eviction: %v, cost %d", l.Keys(false), l.Cost())
+	}
+
+	// too large entries are rejected and drop the old value
+	if l.Add("a", "aaaaaaaaaaa") || l.Contains("a") || l.Cost() != 0 {
+		t.Fatalf("oversized entry cached: %v, cost %d", l.Keys(false), l.Cost())
+	}
+
+	l.Add("d", "dd")
+	l.Add("e", "eeee")
+	l.Add("f", "ffff")
+	if n, err := l.Resize(5); err != nil || n != 2 {
+		t.Fatalf("Resize(5) = %d, %v", n, err)
+	}
+	if l.Cost() != 4 || !l.Contains("f") {
+		t.Fatalf("bad resize: %v, cost %d", l.Keys(false), l.Cost())
+	}
+	if l.Cap() != 5 {
+		t.Fatalf("Cap() = %d, want 5", l.Cap())
+	}
+	l.Purge()
+	if l.Cost() != 0 {
+		t.Fatalf("Cost() after Purge = %d", l.Cost())
+	}
+	if want := []string{"b", "c", "a", "d", "e", "f"}; fmt.Sprint(evicted) != fmt.Sprint(want) {
+		t.Fatalf("evicted %v, want %v", evicted, want)
+	}
+
+	if _, err := lru.NewLRUWithCost[string, string](10, nil, nil); err == nil {
+		t.Fatalf("nil coster should fail")
+	}
+}
+
+func TestLRUWithCost_Negative(t *testing.T) {
+	negative := true
+	l, err := lru.NewLRUWithCost[string, int](10, func(k string, v int) int64 {
+		if negative && v < 0 {
+			return int64(v)
+		}
+		return 1
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// a negative cost would let the cache grow past its max cost
+	l.Add("a", 1)
+	if l.Add("a", -20) || l.Contains("a") || l.Cost() != 0 {
+		t.Fatalf("negative cost cached: %v, cost %d", l.Keys(false), l.Cost())
+	}
+
+	// restored entries are checked as well
+	negative = false
+	l.Add("a", -1)
+	l.Add("b", 2)
+	var buf bytes.Buffer
+	if err := l.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	negative = true
+	if err := l.Restore(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if l.Contains("a") || !l.Contains("b") || l.Cost() != 1 {
+		t.Fatalf("negative cost restored: %v, cost %d", l.Keys(false), l.Cost())
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fast-cache/cache"
+	"fast-cache/lru"
+	"testing"
+	"time"
+)
+
+func TestExpirable_Hidden(t *testing.T) {
+	now := time.Unix(0, 0)
+	l, err := lru.NewExpirableParams[int, int](4, nil, 20*time.Millisecond, lru.ExpirableConfig{
+		Now:        func() time.Time { return now },
+		ManualReap: true,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.AddWithTTL(2, 2, time.Hour)
+	l.AddWithTTL(3, 3, 0)
+	if v, ok := l.Get(1); !ok || v != 1 {
+		t.Fatalf("1 should be set to 1: %v, %v", v, ok)
+	}
+	now = now.Add(30 * time.Millisecond)
+	if _, ok := l.Get(1); ok {
+		t.Errorf("1 should have expired")
+	}
+	if _, ok := l.Peek(1); ok {
+		t.Errorf("1 should have expired")
+	}
+	if l.Contains(1) {
+		t.Errorf("1 should have expired")
+	}
+	if !l.Contains(2) || !l.Contains(3) {
+		t.Errorf("2 and 3 should not have expired")
+	}
+	if keys := l.Keys(false); len(keys) != 2 {
+		t.Errorf("bad keys: %v", keys)
+	}
+	// hidden, but only removed by the reaper
+	if got := l.Len(); got != 3 {
+		t.Errorf("bad len: %d", got)
+	}
+}
+
+func TestExpirable_Reaper(t *testing.T) {
+	now := time.Unix(0, 0)
+	reasons := make(map[int]cache.EvictReason)
+	l, err := lru.NewExpirableParams[int, int](2, func(k int, v int, reason cache.EvictReason) {
+		reasons[k] = reason
+	}, 500*time.Millisecond, lru.ExpirableConfig{
+		Now:        func() time.Time { return now },
+		ManualReap: true,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.Add(1, 1)
+	l.AddWithTTL(2, 2, time.Hour)
+	l.AddWithTTL(3, 3, 100*time.Millisecond)
+	l.Remove(2)
+	// the reaper ticks every 5ms, 3 is reaped on the tick after it expires
+	for i := 0; i < 20; i++ {
+		now = now.Add(5 * time.Millisecond)
+		l.Reap()
+		if got := l.Len(); got != 1 {
+			t.Fatalf("tick %d: bad len: %d", i, got)
+		}
+	}
+	now = now.Add(5 * time.Millisecond)
+	l.Reap()
+	if got := l.Len(); got != 0 {
+		t.Fatalf("bad len: %d", got)
+	}
+
+	want := map[int]cache.EvictReason{
+		1: cache.EvictReasonCapacity,
+		2: cache.EvictReasonRemoved,
+		3: cache.EvictReasonExpired,
+	}
+	for k, r := range want {
+		if reasons[k] != r {
+			t.Errorf("key %d evicted for %v, want %v", k, reasons[k], r)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package exporter
+
+import (
+	"bufio"
+	"errors"
+	"expvar"
+	"fast-cache/cache"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrDuplicateName is returned when registering a cache under a name that
+// is already taken.
+var ErrDuplicateName = errors.New("exporter: cache name already registered")
+
+// Default is the registry published to expvar as "fastcache".
+var Default = NewRegistry()
+
+func init() {
+	expvar.Publish("fastcache", Default.Var())
+}
+
+// Registry exports the size and Stats of named caches. Caches are read
+// from the goroutine serving the metrics, so they must be safe for
+// concurrent use, e.g. synced.Cache, sharded.Sharded or lru.TwoQueueCache.
+type Registry struct {
+	lock   sync.RWMutex
+	caches map[string]*entry
+}
+
+// entry is a registered cache with its type parameters erased.
+type entry struct {
+	name     string
+	policy   string
+	len      func() int
+	capacity func() (int, bool)
+	stats    func() (cache.Stats, bool)
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{caches: make(map[string]*entry)}
+}
+
+// Register adds c to r under name, policy labels the eviction policy. If c
+// is a cache.CapacityProvider its capacity is exported, read on every scrape
+// so it follows Resize. If c is a cache.StatsProvider its stats are enabled,
+// so c must not be shared between goroutines yet. A cache whose name is
+// taken is left untouched.
+func Register[K comparable, V any](r *Registry, name, policy string, c cache.Cache[K, V]) error {
+	if name == "" || c == nil {
+		return errors.New("must provide a name and a cache")
+	}
+	e := &entry{
+		name:     name,
+		policy:   policy,
+		len:      c.Len,
+		capacity: func() (int, bool) { return 0, false },
+		stats:    func() (cache.Stats, bool) { return cache.Stats{}, false },
+	}
+	if cp, ok := c.(cache.CapacityProvider); ok {
+		e.capacity = func() (int, bool) { return cp.Cap(), true }
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.caches[name]; ok {
+		return ErrDuplicateName
+	}
+	if sp, ok := c.(cache.StatsProvider); ok {
+		sp.EnableStats()
+		e.stats = func() (cache.Stats, bool) { return sp.Stats(), true }
+	}
+	r.caches[name] = e
+	return nil
+}
+
+// Unregister removes the cache registered under name, returning if it was
+// registered.
+func (r *Registry) Unregister(name string) (present bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.caches[name]; ok {
+		delete(r.caches, name)
+		return true
+	}
+	return false
+}
+
+// sorted returns the registered caches ordered by name.
+func (r *Registry) sorted() []*entry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	entries := make([]*entry, 0, len(r.caches))
+	for _, e := range r.caches {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries
+}
+
+// cacheVar is the expvar representation of a registered cache.
+type cacheVar struct {
+	Policy    string            `json:"policy"`
+	Len       int               `json:"len"`
+	Capacity  int               `json:"capacity,omitempty"`
+	Hits      uint64            `json:"hits"`
+	Misses    uint64            `json:"misses"`
+	HitRatio  float64           `json:"hit_ratio"`
+	Evictions map[string]uint64 `json:"evictions"`
+}
+
+// Var returns an expvar.Var reporting every registered cache by name.
+func (r *Registry) Var() expvar.Var {
+	return expvar.Func(func() any {
+		vars := make(map[string]cacheVar)
+		for _, e := range r.sorted() {
+			st, _ := e.stats()
+			capacity, _ := e.capacity()
+			v := cacheVar{
+				Policy:    e.policy,
+				Len:       e.len(),
+				Capacity:  capacity,
+				Hits:      st.Hits,
+				Misses:    st.Misses,
+				HitRatio:  st.HitRatio(),
+				Evictions: make(map[string]uint64, len(st.Evictions)),
+			}
+			for reason, n := range st.Evictions {
+				v.Evictions[cache.EvictReason(reason).String()] = n
+			}
+			vars[e.name] = v
+		}
+		return vars
+	})
+}
+
+// WriteMetrics writes the metrics of every registered cache to w in the
+// Prometheus text exposition format.
+func (r *Registry) WriteMetrics(w io.Writer) error {
+	type sample struct {
+		e          *entry
+		len        int
+		capacity   int
+		capacityOK bool
+		stats      cache.Stats
+		ok         bool
+	}
+	entries := r.sorted()
+	samples := make([]sample, len(entries))
+	for i, e := range entries {
+		st, ok := e.stats()
+		capacity, capacityOK := e.capacity()
+		samples[i] = sample{e: e, len: e.len(), capacity: capacity, capacityOK: capacityOK, stats: st, ok: ok}
+	}
+
+	bw := bufio.NewWriter(w)
+	family := func(name, typ, help string, value func(s sample) (uint64, bool)) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, s := range samples {
+			if v, ok := value(s); ok {
+				fmt.Fprintf(bw, "%s{%s} %d\n", name, labels(s.e), v)
+			}
+		}
+	}
+	family("fastcache_entries", "gauge", "Number of entries in the cache.",
+		func(s sample) (uint64, bool) { return uint64(s.len), true })
+	family("fastcache_capacity", "gauge", "Capacity of the cache, in entries or cost units.",
+		func(s sample) (uint64, bool) { return uint64(s.capacity), s.capacityOK })
+	family("fastcache_hits_total", "counter", "Number of Get lookups that found their key.",
+		func(s sample) (uint64, bool) { return s.stats.Hits, s.ok })
+	family("fastcache_misses_total", "counter", "Number of Get lookups that missed.",
+		func(s sample) (uint64, bool) { return s.stats.Misses, s.ok })
+
+	const evictions = "fastcache_evictions_total"
+	fmt.Fprintf(bw, "# HELP %s Number of entries that left the cache, by reason.\n# TYPE %s counter\n", evictions, evictions)
+	for _, s := range samples {
+		if !s.ok {
+			continue
+		}
+		for reason, n := range s.stats.Evictions {
+			fmt.Fprintf(bw, "%s{%s,reason=\"%s\"} %d\n", evictions, labels(s.e), cache.EvictReason(reason), n)
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves WriteMetrics, so a Registry can be mounted as the
+// /metrics handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = r.WriteMetrics(w)
+}
+
+// Handler returns an http.Handler serving the metrics of the Default
+// registry.
+func Handler() http.Handler {
+	return Default
+}
+
+// labels formats the cache and policy labels of e.
+func labels(e *entry) string {
+	return `cache="` + escapeLabel(e.name) + `",policy="` + escapeLabel(e.policy) + `"`
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value as required by the text format.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,111 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fast-cache/synced"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	r := NewRegistry()
+	c, err := synced.NewLRU[int, int](2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := Register[int, int](r, "users", "lru", c); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// a cache under a taken name does not get its stats enabled
+	other, err := synced.NewLRU[int, int](2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := Register[int, int](r, "users", "lru", other); err != ErrDuplicateName {
+		t.Fatalf("duplicate Register = %v", err)
+	}
+	if other.Get(0); other.Stats().Misses != 0 {
+		t.Fatalf("stats should stay disabled: %+v", other.Stats())
+	}
+	for i := 0; i < 3; i++ {
+		c.Add(i, i)
+	}
+	c.Get(2)
+	c.Get(0)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE fastcache_entries gauge\n",
+		`fastcache_entries{cache="users",policy="lru"} 2` + "\n",
+		"# TYPE fastcache_capacity gauge\n",
+		`fastcache_capacity{cache="users",policy="lru"} 2` + "\n",
+		"# TYPE fastcache_hits_total counter\n",
+		`fastcache_hits_total{cache="users",policy="lru"} 1` + "\n",
+		`fastcache_misses_total{cache="users",policy="lru"} 1` + "\n",
+		`fastcache_evictions_total{cache="users",policy="lru",reason="capacity"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+
+	// the capacity follows Resize
+	if _, err := c.Resize(8); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if want := `fastcache_capacity{cache="users",policy="lru"} 8` + "\n"; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("missing %q in:\n%s", want, rec.Body.String())
+	}
+
+	if !r.Unregister("users") || r.Unregister("users") {
+		t.Fatalf("bad Unregister")
+	}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "users") {
+		t.Fatalf("unregistered cache still exported:\n%s", rec.Body.String())
+	}
+}
+
+func TestVar(t *testing.T) {
+	r := NewRegistry()
+	c, err := synced.NewFIFO[int, int](4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := Register[int, int](r, "sessions", "fifo", c); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Get(1)
+	c.Remove(1)
+
+	var got map[string]cacheVar
+	if err := json.Unmarshal([]byte(r.Var().String()), &got); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	v, ok := got["sessions"]
+	if !ok {
+		t.Fatalf("sessions not exported: %v", got)
+	}
+	if v.Policy != "fifo" || v.Capacity != 4 || v.Len != 0 || v.Hits != 1 || v.HitRatio != 1 {
+		t.Fatalf("bad var: %+v", v)
+	}
+	if v.Evictions["removed"] != 1 {
+		t.Fatalf("bad evictions: %v", v.Evictions)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("escapeLabel = %s", got)
+	}
+}
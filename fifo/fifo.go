@@ -2,17 +2,28 @@ package fifo
 
 import (
 	"errors"
+	"fast-cache/cache"
 	"fast-cache/internal"
+	"io"
 )
 
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback[K comparable, V any] func(key K, value V)
 
+var _ cache.Cache[int, int] = (*FIFO[int, int])(nil)
+var _ cache.StatsProvider = (*FIFO[int, int])(nil)
+var _ cache.CapacityProvider = (*FIFO[int, int])(nil)
+var _ cache.Snapshotter = (*FIFO[int, int])(nil)
+
+// FIFO implements a non-thread safe fixed size FIFO cache
 type FIFO[K comparable, V any] struct {
 	size      int
 	evictList *internal.LruList[K, V]
 	items     map[K]*internal.Entry[K, V]
 	onEvict   EvictCallback[K, V]
+	stats     *cache.StatsCounter
+	admit     cache.Admitter[K]
+	codec     cache.Codec[K, V]
 }
 
 // NewFIFO constructs an FIFO of the given size
@@ -32,16 +43,23 @@ func NewFIFO[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*FIFO[
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
 func (c *FIFO[K, V]) Add(key K, value V) (evicted bool) {
+	c.admit.Record(key)
 	// Check for existing item
 	if ent, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ent)
 		ent.Value = value
+		c.stats.Updated()
+		return false
+	}
+
+	if c.admit.Enabled() && c.evictList.Length() >= c.size && !c.admit.Admit(key, c.evictList.Front().Key) {
 		return false
 	}
 
 	// Add new item
 	ent := c.evictList.PushBack(key, value)
 	c.items[key] = ent
+	c.stats.Added()
 
 	evict := c.evictList.Length() > c.size
 	// Verify size not exceeded
@@ -55,7 +73,7 @@ func (c *FIFO[K, V]) Add(key K, value V) (evicted bool) {
 // key was contained.
 func (c *FIFO[K, V]) Remove(key K) (present bool) {
 	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
+		c.removeElement(ent, cache.EvictReasonRemoved)
 		return true
 	}
 	return false
@@ -64,14 +82,15 @@ func (c *FIFO[K, V]) Remove(key K) (present bool) {
 // removeOldest removes the oldest item from the cache.
 func (c *FIFO[K, V]) removeFront() {
 	if ent := c.evictList.Front(); ent != nil {
-		c.removeElement(ent)
+		c.removeElement(ent, cache.EvictReasonCapacity)
 	}
 }
 
 // removeElement is used to remove a given list element from the cache
-func (c *FIFO[K, V]) removeElement(e *internal.Entry[K, V]) {
+func (c *FIFO[K, V]) removeElement(e *internal.Entry[K, V], reason cache.EvictReason) {
 	c.evictList.Remove(e)
 	delete(c.items, e.Key)
+	c.stats.Evicted(reason, 1)
 	if c.onEvict != nil {
 		c.onEvict(e.Key, e.Value)
 	}
@@ -79,10 +98,10 @@ func (c *FIFO[K, V]) removeElement(e *internal.Entry[K, V]) {
 
 // Get looks up a key's value from the cache.
 func (c *FIFO[K, V]) Get(key K) (value V, ok bool) {
-	if ent, ok := c.items[key]; ok {
-		return ent.Value, true
-	}
-	return
+	c.admit.Record(key)
+	value, ok = c.Peek(key)
+	c.stats.Lookup(ok)
+	return value, ok
 }
 
 // Contains checks if a key is in the cache, without updating the recent-ness
@@ -92,6 +111,28 @@ func (c *FIFO[K, V]) Contains(key K) (ok bool) {
 	return ok
 }
 
+// Peek returns the key value (or undefined if not found), FIFO never
+// updates the position of a key on reads so this is Get without counting
+// a hit or miss.
+func (c *FIFO[K, V]) Peek(key K) (value V, ok bool) {
+	if ent, ok := c.items[key]; ok {
+		return ent.Value, true
+	}
+	return
+}
+
+// Purge is used to completely clear the cache.
+func (c *FIFO[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, v := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, v.Value)
+		}
+		delete(c.items, k)
+	}
+	c.evictList.Init()
+}
+
 // Keys returns a slice of the keys in the cache, from oldest to newest.
 func (c *FIFO[K, V]) Keys(reverse bool) []K {
 	keys := make([]K, c.evictList.Length())
@@ -133,6 +174,11 @@ func (c *FIFO[K, V]) Len() int {
 	return c.evictList.Length()
 }
 
+// Cap returns the size of the cache.
+func (c *FIFO[K, V]) Cap() int {
+	return c.size
+}
+
 // Resize changes the cache size.
 func (c *FIFO[K, V]) Resize(size int) (evicted int, err error) {
 	if size <= 0 {
@@ -148,3 +194,59 @@ func (c *FIFO[K, V]) Resize(size int) (evicted int, err error) {
 	c.size = size
 	return diff, nil
 }
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *FIFO[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *FIFO[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// SetAdmission installs the admission policy consulted before a new key
+// evicts an entry, nil admits every key.
+func (c *FIFO[K, V]) SetAdmission(admission cache.Admission[K]) {
+	c.admit.Set(admission)
+}
+
+// SetCodec sets the codec of Snapshot and Restore, nil is cache.GobCodec.
+func (c *FIFO[K, V]) SetCodec(codec cache.Codec[K, V]) {
+	c.codec = codec
+}
+
+// Snapshot writes the entries of the cache to w, from oldest to newest.
+func (c *FIFO[K, V]) Snapshot(w io.Writer) error {
+	s := &cache.Snapshot[K, V]{
+		Policy:  "fifo",
+		Entries: make([]cache.SnapshotEntry[K, V], 0, len(c.items)),
+	}
+	for ent := c.evictList.Front(); ent != nil; ent = ent.NextEntry() {
+		s.Entries = append(s.Entries, cache.SnapshotEntry[K, V]{Key: ent.Key, Value: ent.Value})
+	}
+	return cache.WriteSnapshot(w, c.codec, s)
+}
+
+// Restore purges the cache and loads a snapshot written by Snapshot, in
+// the same order. Entries that do not fit are evicted, oldest first.
+func (c *FIFO[K, V]) Restore(r io.Reader) error {
+	s, err := cache.ReadSnapshot(r, "fifo", c.codec)
+	if err != nil {
+		return err
+	}
+	c.Purge()
+	for _, e := range s.Entries {
+		if ent, ok := c.items[e.Key]; ok {
+			c.removeElement(ent, cache.EvictReasonRemoved)
+		}
+		c.items[e.Key] = c.evictList.PushBack(e.Key, e.Value)
+	}
+	for c.evictList.Length() > c.size {
+		c.removeFront()
+	}
+	return nil
+}
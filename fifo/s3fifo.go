@@ -0,0 +1,334 @@
+package fifo
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+)
+
+var _ cache.Cache[int, int] = (*S3FIFO[int, int])(nil)
+var _ cache.StatsProvider = (*S3FIFO[int, int])(nil)
+var _ cache.CapacityProvider = (*S3FIFO[int, int])(nil)
+
+// DefaultSmallRatio is the share of the S3FIFO cache given to the small
+// FIFO.
+const DefaultSmallRatio = 0.10
+
+// maxFreq caps the 2-bit frequency of an S3FIFO entry.
+const maxFreq = 3
+
+// s3Node is an entry with its access frequency and the queue it is in.
+type s3Node[K comparable, V any] struct {
+	internal.Entry[K, V]
+	freq uint8
+	main bool
+}
+
+// S3FIFO implements a non-thread safe fixed size S3-FIFO cache. New keys
+// enter a small FIFO, and only those read again before leaving it move to
+// the main FIFO, the others are evicted and remembered in a ghost FIFO of
+// keys so they go straight to main if they come back. The main FIFO
+// reinserts entries read since their last pass, with a frequency capped
+// at 3, instead of evicting them. Reads only bump a counter, they never
+// move entries.
+type S3FIFO[K comparable, V any] struct {
+	size       int
+	smallSize  int
+	smallRatio float64
+
+	items map[K]*s3Node[K, V]
+	small internal.LruList[K, V]
+	main  internal.LruList[K, V]
+
+	// ghost holds the keys recently evicted from the small FIFO, at most
+	// size of them.
+	ghost      internal.LruList[K, struct{}]
+	ghostItems map[K]*internal.Entry[K, struct{}]
+
+	onEvict EvictCallback[K, V]
+	stats   *cache.StatsCounter
+}
+
+// NewS3FIFO constructs an S3FIFO of the given size using the default small
+// FIFO ratio.
+func NewS3FIFO[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*S3FIFO[K, V], error) {
+	return NewS3FIFOParams[K, V](size, DefaultSmallRatio, onEvict)
+}
+
+// NewS3FIFOParams constructs an S3FIFO of the given size, smallRatio is the
+// share of the cache given to the small FIFO.
+func NewS3FIFOParams[K comparable, V any](size int, smallRatio float64, onEvict EvictCallback[K, V]) (*S3FIFO[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if smallRatio <= 0.0 || smallRatio >= 1.0 {
+		return nil, errors.New("invalid small ratio")
+	}
+
+	c := &S3FIFO[K, V]{
+		smallRatio: smallRatio,
+		items:      make(map[K]*s3Node[K, V], size),
+		ghostItems: make(map[K]*internal.Entry[K, struct{}], size),
+		onEvict:    onEvict,
+	}
+	c.setSize(size)
+	return c, nil
+}
+
+// setSize sets the cache size and the share of the small FIFO.
+func (c *S3FIFO[K, V]) setSize(size int) {
+	c.size = size
+	c.smallSize = max(1, int(float64(size)*c.smallRatio))
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *S3FIFO[K, V]) Add(key K, value V) (evicted bool) {
+	// Check for existing item
+	if n, ok := c.items[key]; ok {
+		n.Value = value
+		n.touch()
+		c.stats.Updated()
+		return false
+	}
+
+	if len(c.items) >= c.size {
+		c.evict()
+		evicted = true
+	}
+
+	n := &s3Node[K, V]{}
+	n.Key, n.Value = key, value
+	if g, ok := c.ghostItems[key]; ok {
+		c.ghost.Remove(g)
+		delete(c.ghostItems, key)
+		n.main = true
+		c.main.PushEntryFront(&n.Entry)
+		c.stats.GhostHit()
+	} else {
+		c.small.PushEntryFront(&n.Entry)
+	}
+	c.items[key] = n
+	c.stats.Added()
+	return evicted
+}
+
+// touch records a read of n, saturating at maxFreq.
+func (n *s3Node[K, V]) touch() {
+	if n.freq < maxFreq {
+		n.freq++
+	}
+}
+
+// evict removes one entry, from the small FIFO while it is over its share
+// and from the main FIFO otherwise.
+func (c *S3FIFO[K, V]) evict() {
+	if c.small.Length() > c.smallSize || c.main.Length() == 0 {
+		if c.evictSmall() {
+			return
+		}
+	}
+	c.evictMain()
+}
+
+// evictSmall moves the oldest entries of the small FIFO that were read to
+// the main FIFO until it finds one that was not, which is evicted and
+// remembered in the ghost FIFO. Returns false if the small FIFO emptied
+// without an eviction.
+func (c *S3FIFO[K, V]) evictSmall() bool {
+	for e := c.small.Back(); e != nil; e = c.small.Back() {
+		n := c.items[e.Key]
+		c.small.Remove(&n.Entry)
+		if n.freq > 0 {
+			n.freq = 0
+			n.main = true
+			c.main.PushEntryFront(&n.Entry)
+			c.stats.Promoted()
+			continue
+		}
+		c.addGhost(n.Key)
+		c.evictNode(n, cache.EvictReasonCapacity)
+		return true
+	}
+	return false
+}
+
+// evictMain reinserts the oldest entries of the main FIFO that were read,
+// decrementing their frequency, until it evicts one that was not.
+func (c *S3FIFO[K, V]) evictMain() {
+	for e := c.main.Back(); e != nil; e = c.main.Back() {
+		n := c.items[e.Key]
+		if n.freq > 0 {
+			n.freq--
+			c.main.MoveToFront(&n.Entry)
+			continue
+		}
+		c.main.Remove(&n.Entry)
+		c.evictNode(n, cache.EvictReasonCapacity)
+		return
+	}
+}
+
+// addGhost remembers key in the ghost FIFO.
+func (c *S3FIFO[K, V]) addGhost(key K) {
+	c.ghostItems[key] = c.ghost.PushFront(key, struct{}{})
+	c.trimGhost()
+}
+
+// trimGhost forgets the oldest ghost keys past the cache size.
+func (c *S3FIFO[K, V]) trimGhost() {
+	for c.ghost.Length() > c.size {
+		g := c.ghost.Back()
+		c.ghost.Remove(g)
+		delete(c.ghostItems, g.Key)
+	}
+}
+
+// list returns the queue n is in.
+func (c *S3FIFO[K, V]) list(n *s3Node[K, V]) *internal.LruList[K, V] {
+	if n.main {
+		return &c.main
+	}
+	return &c.small
+}
+
+// evictNode drops n, which must already be unlinked, from the cache.
+func (c *S3FIFO[K, V]) evictNode(n *s3Node[K, V], reason cache.EvictReason) {
+	delete(c.items, n.Key)
+	c.stats.Evicted(reason, 1)
+	if c.onEvict != nil {
+		c.onEvict(n.Key, n.Value)
+	}
+}
+
+// Get looks up a key's value from the cache.
+func (c *S3FIFO[K, V]) Get(key K) (value V, ok bool) {
+	if n, ok := c.items[key]; ok {
+		n.touch()
+		c.stats.Hit()
+		return n.Value, true
+	}
+	c.stats.Miss()
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the frequency of the key.
+func (c *S3FIFO[K, V]) Peek(key K) (value V, ok bool) {
+	if n, ok := c.items[key]; ok {
+		return n.Value, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without updating the frequency
+// of the key.
+func (c *S3FIFO[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained. A ghost of the key is forgotten.
+func (c *S3FIFO[K, V]) Remove(key K) (present bool) {
+	if n, ok := c.items[key]; ok {
+		c.list(n).Remove(&n.Entry)
+		c.evictNode(n, cache.EvictReasonRemoved)
+		return true
+	}
+	if g, ok := c.ghostItems[key]; ok {
+		c.ghost.Remove(g)
+		delete(c.ghostItems, key)
+	}
+	return false
+}
+
+// entries returns the entries of the small FIFO then the main FIFO, each
+// from oldest to newest.
+func (c *S3FIFO[K, V]) entries(reverse bool) []*internal.Entry[K, V] {
+	entries := make([]*internal.Entry[K, V], 0, len(c.items))
+	for _, l := range []*internal.LruList[K, V]{&c.small, &c.main} {
+		for e := l.Back(); e != nil; e = e.PrevEntry() {
+			entries = append(entries, e)
+		}
+	}
+	if reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	return entries
+}
+
+// Keys returns a slice of the keys in the cache, the small FIFO then the
+// main FIFO, each from oldest to newest.
+func (c *S3FIFO[K, V]) Keys(reverse bool) []K {
+	entries := c.entries(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, in the same order as
+// Keys.
+func (c *S3FIFO[K, V]) Values(reverse bool) []V {
+	entries := c.entries(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Value
+	}
+	return values
+}
+
+// Len returns the number of items in the cache.
+func (c *S3FIFO[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Cap returns the size of the cache.
+func (c *S3FIFO[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache and its ghost FIFO.
+func (c *S3FIFO[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, n := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, n.Value)
+		}
+		delete(c.items, k)
+	}
+	c.small.Init()
+	c.main.Init()
+	c.ghost.Init()
+	c.ghostItems = make(map[K]*internal.Entry[K, struct{}], c.size)
+}
+
+// Resize changes the cache size.
+func (c *S3FIFO[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	c.setSize(size)
+	for len(c.items) > size {
+		c.evict()
+		evicted++
+	}
+	c.trimGhost()
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *S3FIFO[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *S3FIFO[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
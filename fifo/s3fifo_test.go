@@ -0,0 +1,126 @@
+package fifo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestS3FIFO(t *testing.T) {
+	c, err := NewS3FIFO[int, int](10, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.EnableStats()
+	for i := 0; i < 10; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < 5; i++ {
+		c.Get(i)
+	}
+
+	// the keys read while in the small FIFO move to main, 5 is evicted
+	if !c.Add(10, 10) {
+		t.Fatalf("should evict")
+	}
+	if c.Contains(5) {
+		t.Fatalf("5 should be evicted")
+	}
+	for i := 0; i < 5; i++ {
+		if !c.Contains(i) {
+			t.Fatalf("%d should be in main", i)
+		}
+	}
+
+	// 5 is a ghost so it goes straight to main, evicting 6 from small
+	c.Add(5, 5)
+	if c.Contains(6) {
+		t.Fatalf("6 should be evicted")
+	}
+	want := []int{7, 8, 9, 10, 0, 1, 2, 3, 4, 5}
+	if keys := c.Keys(false); !reflect.DeepEqual(keys, want) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if values := c.Values(false); !reflect.DeepEqual(values, want) {
+		t.Fatalf("bad values: %v", values)
+	}
+	s := c.Stats()
+	if s.GhostHits != 1 || s.Promotions != 5 || s.TotalEvictions() != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	if evicted, _ := c.Resize(5); evicted != 5 || c.Len() != 5 {
+		t.Fatalf("bad resize: %d %d", evicted, c.Len())
+	}
+	c.Purge()
+	if c.Len() != 0 || len(c.ghostItems) != 0 {
+		t.Fatalf("bad purge")
+	}
+}
+
+func TestS3FIFO_MainReinsert(t *testing.T) {
+	c, err := NewS3FIFOParams[int, int](4, 0.25, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// fill main with 0, 1, 2 through the small FIFO
+	for i := 0; i < 3; i++ {
+		c.Add(i, i)
+		c.Get(i)
+	}
+	c.Add(3, 3)
+	c.Add(4, 4)
+	if c.Contains(3) {
+		t.Fatalf("3 should be evicted from small")
+	}
+
+	// 0 was read again in main so it is reinserted, 1 goes instead
+	c.Get(0)
+	c.Add(5, 5)
+	c.Add(6, 6)
+	if !c.Contains(0) || c.Contains(1) {
+		t.Fatalf("bad keys: %v", c.Keys(false))
+	}
+}
+
+func TestS3FIFO_ScanResistance(t *testing.T) {
+	s3, err := NewS3FIFO[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	f, err := NewFIFO[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		s3.Add(i, i)
+		f.Add(i, i)
+		s3.Get(i)
+		f.Get(i)
+	}
+	// a scan of keys read only once
+	for i := 1000; i < 2000; i++ {
+		s3.Add(i, i)
+		f.Add(i, i)
+	}
+	var s3Hot, fHot int
+	for i := 0; i < 50; i++ {
+		if s3.Contains(i) {
+			s3Hot++
+		}
+		if f.Contains(i) {
+			fHot++
+		}
+	}
+	if s3Hot != 50 || fHot != 0 {
+		t.Fatalf("hot keys left: s3fifo %d fifo %d", s3Hot, fHot)
+	}
+}
+
+func TestS3FIFO_Invalid(t *testing.T) {
+	if _, err := NewS3FIFO[int, int](0, nil); err == nil {
+		t.Fatalf("should fail on size 0")
+	}
+	if _, err := NewS3FIFOParams[int, int](10, 1.0, nil); err == nil {
+		t.Fatalf("should fail on ratio 1")
+	}
+}
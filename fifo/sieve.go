@@ -0,0 +1,229 @@
+package fifo
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+)
+
+var _ cache.Cache[int, int] = (*Sieve[int, int])(nil)
+var _ cache.StatsProvider = (*Sieve[int, int])(nil)
+var _ cache.CapacityProvider = (*Sieve[int, int])(nil)
+
+// sieveNode is an entry with its visited bit.
+type sieveNode[K comparable, V any] struct {
+	internal.Entry[K, V]
+	visited bool
+}
+
+// Sieve implements a non-thread safe fixed size SIEVE cache. Entries are
+// kept in a FIFO queue and a hit only sets their visited bit. To evict, a
+// hand moves from the oldest entry towards the newest clearing visited
+// bits, wrapping around, and evicts the first entry that was not visited.
+// Visited entries are retained in place, and the hand stays where it
+// stopped for the next eviction.
+type Sieve[K comparable, V any] struct {
+	size    int
+	items   map[K]*sieveNode[K, V]
+	queue   internal.LruList[K, V] // newest at the front
+	hand    *sieveNode[K, V]       // next entry to inspect, nil for the oldest
+	onEvict EvictCallback[K, V]
+	stats   *cache.StatsCounter
+}
+
+// NewSieve constructs a Sieve of the given size
+func NewSieve[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*Sieve[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+
+	c := &Sieve[K, V]{
+		size:    size,
+		items:   make(map[K]*sieveNode[K, V], size),
+		onEvict: onEvict,
+	}
+	return c, nil
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *Sieve[K, V]) Add(key K, value V) (evicted bool) {
+	// Check for existing item
+	if n, ok := c.items[key]; ok {
+		n.Value = value
+		n.visited = true
+		c.stats.Updated()
+		return false
+	}
+
+	if len(c.items) >= c.size {
+		c.evict()
+		evicted = true
+	}
+
+	n := &sieveNode[K, V]{}
+	n.Key, n.Value = key, value
+	c.queue.PushEntryFront(&n.Entry)
+	c.items[key] = n
+	c.stats.Added()
+	return evicted
+}
+
+// evict moves the hand towards the newest entry, clearing visited bits,
+// and evicts the first entry that was not visited.
+func (c *Sieve[K, V]) evict() {
+	n := c.hand
+	for {
+		if n == nil {
+			e := c.queue.Back()
+			if e == nil {
+				return
+			}
+			n = c.items[e.Key]
+		}
+		if !n.visited {
+			break
+		}
+		n.visited = false
+		n = c.prev(n)
+	}
+	c.hand = c.prev(n)
+	c.removeNode(n, cache.EvictReasonCapacity)
+}
+
+// prev returns the entry after n towards the newest, nil if n is the
+// newest.
+func (c *Sieve[K, V]) prev(n *sieveNode[K, V]) *sieveNode[K, V] {
+	if e := n.PrevEntry(); e != nil {
+		return c.items[e.Key]
+	}
+	return nil
+}
+
+// removeNode is used to remove a given entry from the cache
+func (c *Sieve[K, V]) removeNode(n *sieveNode[K, V], reason cache.EvictReason) {
+	if c.hand == n {
+		c.hand = c.prev(n)
+	}
+	c.queue.Remove(&n.Entry)
+	delete(c.items, n.Key)
+	c.stats.Evicted(reason, 1)
+	if c.onEvict != nil {
+		c.onEvict(n.Key, n.Value)
+	}
+}
+
+// Get looks up a key's value from the cache.
+func (c *Sieve[K, V]) Get(key K) (value V, ok bool) {
+	if n, ok := c.items[key]; ok {
+		n.visited = true
+		c.stats.Hit()
+		return n.Value, true
+	}
+	c.stats.Miss()
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without setting
+// the visited bit of the key.
+func (c *Sieve[K, V]) Peek(key K) (value V, ok bool) {
+	if n, ok := c.items[key]; ok {
+		return n.Value, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without setting the visited
+// bit of the key.
+func (c *Sieve[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Sieve[K, V]) Remove(key K) (present bool) {
+	if n, ok := c.items[key]; ok {
+		c.removeNode(n, cache.EvictReasonRemoved)
+		return true
+	}
+	return false
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest.
+func (c *Sieve[K, V]) Keys(reverse bool) []K {
+	keys := make([]K, 0, len(c.items))
+	if reverse {
+		for e := c.queue.Front(); e != nil; e = e.NextEntry() {
+			keys = append(keys, e.Key)
+		}
+	} else {
+		for e := c.queue.Back(); e != nil; e = e.PrevEntry() {
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, from oldest to newest.
+func (c *Sieve[K, V]) Values(reverse bool) []V {
+	values := make([]V, 0, len(c.items))
+	if reverse {
+		for e := c.queue.Front(); e != nil; e = e.NextEntry() {
+			values = append(values, e.Value)
+		}
+	} else {
+		for e := c.queue.Back(); e != nil; e = e.PrevEntry() {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+// Len returns the number of items in the cache.
+func (c *Sieve[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Cap returns the size of the cache.
+func (c *Sieve[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache.
+func (c *Sieve[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, n := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, n.Value)
+		}
+		delete(c.items, k)
+	}
+	c.queue.Init()
+	c.hand = nil
+}
+
+// Resize changes the cache size.
+func (c *Sieve[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	for len(c.items) > size {
+		c.evict()
+		evicted++
+	}
+	c.size = size
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *Sieve[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Sieve[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
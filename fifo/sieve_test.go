@@ -0,0 +1,75 @@
+package fifo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSieve(t *testing.T) {
+	var evicted []int
+	c, err := NewSieve[int, int](4, func(k, v int) { evicted = append(evicted, k) })
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		c.Add(i, i)
+	}
+	c.Get(1)
+	c.Get(3)
+
+	// the hand skips the visited 1 and evicts 2, retaining 1 in place
+	c.Add(5, 5)
+	if keys := c.Keys(false); !reflect.DeepEqual(keys, []int{1, 3, 4, 5}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if keys := c.Keys(true); !reflect.DeepEqual(keys, []int{5, 4, 3, 1}) {
+		t.Fatalf("bad reversed keys: %v", keys)
+	}
+
+	// the hand resumes at 3, clears it and evicts 4
+	c.Add(6, 6)
+	// the hand resumes at 5 and evicts it, then 6 which was added after 5
+	c.Add(7, 7)
+	c.Add(8, 8)
+	if want := []int{2, 4, 5, 6}; !reflect.DeepEqual(evicted, want) {
+		t.Fatalf("bad evicted: %v want %v", evicted, want)
+	}
+	if keys := c.Keys(false); !reflect.DeepEqual(keys, []int{1, 3, 7, 8}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	// removing the entry under the hand moves the hand on to 8
+	c.Remove(7)
+	c.Add(9, 9)
+	c.Add(10, 10)
+	if c.Contains(8) || !c.Contains(1) {
+		t.Fatalf("bad keys: %v", c.Keys(false))
+	}
+	// the hand clears 9 and 10 and wraps around to the oldest entry
+	c.Get(9)
+	c.Get(10)
+	c.Add(11, 11)
+	if keys := c.Keys(false); !reflect.DeepEqual(keys, []int{3, 9, 10, 11}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestSieve_ScanResistance(t *testing.T) {
+	s, err := NewSieve[int, int](100, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		s.Add(i, i)
+	}
+	// the hot keys are read between every scanned key
+	for i := 1000; i < 2000; i++ {
+		s.Add(i, i)
+		s.Get(i % 50)
+	}
+	for i := 0; i < 50; i++ {
+		if !s.Contains(i) {
+			t.Fatalf("hot key %d evicted", i)
+		}
+	}
+}
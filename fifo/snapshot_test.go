@@ -0,0 +1,33 @@
+package fifo
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFIFO_Snapshot(t *testing.T) {
+	c, err := NewFIFO[string, int](3, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i, k := range []string{"a", "b", "c", "d"} {
+		c.Add(k, i)
+	}
+	var buf bytes.Buffer
+	if err := c.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, _ := NewFIFO[string, int](3, nil)
+	if err := r.Restore(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(r.Keys(false), c.Keys(false)) || !reflect.DeepEqual(r.Values(false), c.Values(false)) {
+		t.Fatalf("bad: %v %v", r.Keys(false), r.Values(false))
+	}
+	// the oldest entry is still the first to go
+	r.Add("e", 4)
+	if r.Contains("b") || !r.Contains("c") || !r.Contains("e") {
+		t.Fatalf("bad: %v", r.Keys(false))
+	}
+}
@@ -0,0 +1,25 @@
+package fifo
+
+import (
+	"fast-cache/cache"
+	"fast-cache/workload/workloadtest"
+	"testing"
+)
+
+func BenchmarkFIFO_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewFIFO[uint64, uint64](size, nil)
+	})
+}
+
+func BenchmarkS3FIFO_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewS3FIFO[uint64, uint64](size, nil)
+	})
+}
+
+func BenchmarkSieve_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewSieve[uint64, uint64](size, nil)
+	})
+}
@@ -1,3 +1,3 @@
 module fast-cache
 
-go 1.21
+go 1.24
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// seed seeds the hashes of the key types Hash has no fast path for.
+var seed = maphash.MakeSeed()
+
+// Hash returns a 64-bit hash of any comparable key, equal keys hash equally.
+// Common key types are hashed directly and the same in every process,
+// anything else with maphash, which differs between processes.
+func Hash[K comparable](key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return hashString(k)
+	case int:
+		return Mix64(uint64(k))
+	case int8:
+		return Mix64(uint64(k))
+	case int16:
+		return Mix64(uint64(k))
+	case int32:
+		return Mix64(uint64(k))
+	case int64:
+		return Mix64(uint64(k))
+	case uint:
+		return Mix64(uint64(k))
+	case uint8:
+		return Mix64(uint64(k))
+	case uint16:
+		return Mix64(uint64(k))
+	case uint32:
+		return Mix64(uint64(k))
+	case uint64:
+		return Mix64(k)
+	case uintptr:
+		return Mix64(uint64(k))
+	case float32:
+		if k == 0 {
+			k = 0 // -0 == +0
+		}
+		return Mix64(uint64(math.Float32bits(k)))
+	case float64:
+		if k == 0 {
+			k = 0 // -0 == +0
+		}
+		return Mix64(math.Float64bits(k))
+	case bool:
+		if k {
+			return Mix64(1)
+		}
+		return Mix64(0)
+	}
+	return maphash.Comparable(seed, key)
+}
+
+// hashString returns the 64-bit FNV-1a hash of s.
+func hashString(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// Mix64 is the splitmix64 finalizer, it spreads the bits of x so that
+// sequential keys land in different shards and sketch counters.
+func Mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -25,6 +25,9 @@ type Entry[K comparable, V any] struct {
 
 	// The expiry bucket item was put in, optional
 	ExpireBucket uint8
+
+	// The cost of this element in a cost bounded cache, optional
+	Cost int64
 }
 
 // PrevEntry returns the previous list element or nil.
@@ -139,6 +142,13 @@ func (l *LruList[K, V]) PushBack(k K, v V) *Entry[K, V] {
 	return l.insertValue(k, v, time.Time{}, l.dummy.prev)
 }
 
+// PushEntryFront inserts e, which must not belong to a list, at the front
+// of list l and returns e.
+func (l *LruList[K, V]) PushEntryFront(e *Entry[K, V]) *Entry[K, V] {
+	l.lazyInit()
+	return l.insert(e, &l.dummy)
+}
+
 // PushFrontExpirable inserts a new expirable element e with Value v at the front of list l and returns e.
 func (l *LruList[K, V]) PushFrontExpirable(k K, v V, expiresAt time.Time) *Entry[K, V] {
 	l.lazyInit()
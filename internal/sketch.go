@@ -0,0 +1,167 @@
+package internal
+
+import "math/bits"
+
+// sketchDepth is the number of rows of a CountMinSketch, each key has one
+// counter per row.
+const sketchDepth = 4
+
+// CountMinSketch estimates how often keys were seen with 4-bit counters,
+// saturating at 15. Once the number of increments reaches the sample size
+// every counter is halved, so old popularity fades.
+type CountMinSketch struct {
+	rows       [sketchDepth][]uint64 // 16 counters per word
+	mask       uint64                // counters per row - 1
+	additions  int
+	sampleSize int
+}
+
+// NewCountMinSketch creates a sketch sized for a cache of the given size.
+func NewCountMinSketch(size int) *CountMinSketch {
+	width := nextPowerOfTwo(max(size, 16))
+	s := &CountMinSketch{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * max(size, 1),
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, width/16)
+	}
+	return s
+}
+
+// index returns the word and shift of the counter of hash h in row i.
+func (s *CountMinSketch) index(h uint64, i int) (word int, shift uint) {
+	// double hashing, the second hash is made odd so rows differ
+	n := (h + uint64(i)*(Mix64(h)|1)) & s.mask
+	return int(n >> 4), uint(n&15) << 2
+}
+
+// Increment counts one occurrence of hash h. Returns true if the sketch
+// was reset by halving its counters.
+func (s *CountMinSketch) Increment(h uint64) (reset bool) {
+	for i := range s.rows {
+		w, shift := s.index(h, i)
+		if (s.rows[i][w]>>shift)&15 < 15 {
+			s.rows[i][w] += 1 << shift
+		}
+	}
+	if s.additions++; s.additions >= s.sampleSize {
+		s.halve()
+		return true
+	}
+	return false
+}
+
+// Estimate returns the estimated number of occurrences of hash h.
+func (s *CountMinSketch) Estimate(h uint64) int {
+	est := uint64(15)
+	for i := range s.rows {
+		w, shift := s.index(h, i)
+		est = min(est, (s.rows[i][w]>>shift)&15)
+	}
+	return int(est)
+}
+
+// halve divides every counter by two.
+func (s *CountMinSketch) halve() {
+	for i := range s.rows {
+		for j, w := range s.rows[i] {
+			s.rows[i][j] = (w >> 1) & 0x7777777777777777
+		}
+	}
+	s.additions /= 2
+}
+
+// Reset zeroes every counter.
+func (s *CountMinSketch) Reset() {
+	for i := range s.rows {
+		clear(s.rows[i])
+	}
+	s.additions = 0
+}
+
+// Doorkeeper is a bloom filter remembering which keys were seen once, so
+// keys seen a single time never reach the sketch.
+type Doorkeeper struct {
+	bits []uint64
+	mask uint64
+}
+
+// NewDoorkeeper creates a Doorkeeper sized for a cache of the given size.
+func NewDoorkeeper(size int) *Doorkeeper {
+	n := nextPowerOfTwo(max(size, 8) * 8)
+	return &Doorkeeper{bits: make([]uint64, n/64), mask: uint64(n - 1)}
+}
+
+// Allow records hash h, returning if it was already present.
+func (d *Doorkeeper) Allow(h uint64) (present bool) {
+	present = true
+	h2 := Mix64(h) | 1
+	for i := uint64(0); i < 3; i++ {
+		n := (h + i*h2) & d.mask
+		if d.bits[n>>6]&(1<<(n&63)) == 0 {
+			present = false
+			d.bits[n>>6] |= 1 << (n & 63)
+		}
+	}
+	return present
+}
+
+// Contains returns if hash h was recorded.
+func (d *Doorkeeper) Contains(h uint64) bool {
+	h2 := Mix64(h) | 1
+	for i := uint64(0); i < 3; i++ {
+		n := (h + i*h2) & d.mask
+		if d.bits[n>>6]&(1<<(n&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reset forgets every recorded hash.
+func (d *Doorkeeper) Reset() {
+	clear(d.bits)
+}
+
+// TinyLFU estimates key frequencies with a Doorkeeper in front of a
+// CountMinSketch, the doorkeeper is cleared whenever the sketch is halved.
+type TinyLFU struct {
+	sketch *CountMinSketch
+	door   *Doorkeeper
+}
+
+// NewTinyLFU creates a TinyLFU sized for a cache of the given size.
+func NewTinyLFU(size int) *TinyLFU {
+	return &TinyLFU{sketch: NewCountMinSketch(size), door: NewDoorkeeper(size)}
+}
+
+// Increment records an access to hash h.
+func (t *TinyLFU) Increment(h uint64) {
+	if !t.door.Allow(h) {
+		return
+	}
+	if t.sketch.Increment(h) {
+		t.door.Reset()
+	}
+}
+
+// Estimate returns the estimated access frequency of hash h.
+func (t *TinyLFU) Estimate(h uint64) int {
+	n := t.sketch.Estimate(h)
+	if t.door.Contains(h) {
+		n++
+	}
+	return n
+}
+
+// Reset forgets every recorded access.
+func (t *TinyLFU) Reset() {
+	t.sketch.Reset()
+	t.door.Reset()
+}
+
+// nextPowerOfTwo returns the smallest power of two >= n, n must be positive.
+func nextPowerOfTwo(n int) int {
+	return 1 << bits.Len(uint(n-1))
+}
@@ -0,0 +1,264 @@
+package lfu
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+)
+
+var _ cache.Cache[int, int] = (*BucketLFU[int, int])(nil)
+var _ cache.StatsProvider = (*BucketLFU[int, int])(nil)
+var _ cache.CapacityProvider = (*BucketLFU[int, int])(nil)
+
+// BucketLFU implements a non-thread safe fixed size LFU cache in constant
+// time. Entries are grouped in a doubly-linked list of buckets, one per
+// reference count in ascending order, and each bucket is an LRU list so
+// ties are broken by recency without reading the clock.
+type BucketLFU[K comparable, V any] struct {
+	size    int
+	items   map[K]*bucketItem[K, V]
+	head    *freqBucket[K, V] // bucket of the lowest reference count
+	onEvict EvictCallback[K, V]
+	stats   *cache.StatsCounter
+}
+
+// freqBucket holds the entries referenced freq times, most recent first.
+type freqBucket[K comparable, V any] struct {
+	freq       uint64
+	prev, next *freqBucket[K, V]
+	entries    internal.LruList[K, V]
+}
+
+// bucketItem is an entry with the bucket it belongs to.
+type bucketItem[K comparable, V any] struct {
+	internal.Entry[K, V]
+	bucket *freqBucket[K, V]
+}
+
+// NewBucketLFU constructs a BucketLFU of the given size
+func NewBucketLFU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*BucketLFU[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+
+	c := &BucketLFU[K, V]{
+		size:    size,
+		items:   make(map[K]*bucketItem[K, V], size),
+		onEvict: onEvict,
+	}
+	return c, nil
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *BucketLFU[K, V]) Add(key K, value V) (evicted bool) {
+	// Check for existing item
+	if it, ok := c.items[key]; ok {
+		it.Value = value
+		c.increment(it)
+		c.stats.Updated()
+		return false
+	}
+	if len(c.items) >= c.size {
+		c.removeLeast(cache.EvictReasonCapacity)
+		evicted = true
+	}
+
+	b := c.head
+	if b == nil || b.freq != 1 {
+		b = c.insertBucket(nil, 1)
+	}
+	it := &bucketItem[K, V]{bucket: b}
+	it.Key, it.Value = key, value
+	b.entries.PushEntryFront(&it.Entry)
+	c.items[key] = it
+	c.stats.Added()
+	return evicted
+}
+
+// Get looks up a key's value from the cache.
+func (c *BucketLFU[K, V]) Get(key K) (value V, ok bool) {
+	if it, ok := c.items[key]; ok {
+		c.increment(it)
+		c.stats.Hit()
+		return it.Value, true
+	}
+	c.stats.Miss()
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the reference count of the key.
+func (c *BucketLFU[K, V]) Peek(key K) (value V, ok bool) {
+	if it, ok := c.items[key]; ok {
+		return it.Value, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without updating the reference
+// count.
+func (c *BucketLFU[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *BucketLFU[K, V]) Remove(key K) (present bool) {
+	if it, ok := c.items[key]; ok {
+		c.removeItem(it, cache.EvictReasonRemoved)
+		return true
+	}
+	return false
+}
+
+// increment moves it to the bucket of the next reference count.
+func (c *BucketLFU[K, V]) increment(it *bucketItem[K, V]) {
+	b := it.bucket
+	next := b.next
+	if next == nil || next.freq != b.freq+1 {
+		next = c.insertBucket(b, b.freq+1)
+	}
+	b.entries.Remove(&it.Entry)
+	next.entries.PushEntryFront(&it.Entry)
+	it.bucket = next
+	if b.entries.Length() == 0 {
+		c.removeBucket(b)
+	}
+}
+
+// insertBucket links a new bucket for freq after prev, or at the head if
+// prev is nil.
+func (c *BucketLFU[K, V]) insertBucket(prev *freqBucket[K, V], freq uint64) *freqBucket[K, V] {
+	b := &freqBucket[K, V]{freq: freq, prev: prev}
+	if prev == nil {
+		b.next = c.head
+		c.head = b
+	} else {
+		b.next = prev.next
+		prev.next = b
+	}
+	if b.next != nil {
+		b.next.prev = b
+	}
+	return b
+}
+
+// removeBucket unlinks the empty bucket b.
+func (c *BucketLFU[K, V]) removeBucket(b *freqBucket[K, V]) {
+	if b.prev == nil {
+		c.head = b.next
+	} else {
+		b.prev.next = b.next
+	}
+	if b.next != nil {
+		b.next.prev = b.prev
+	}
+}
+
+// removeLeast removes the least recently used entry of the lowest
+// reference count.
+func (c *BucketLFU[K, V]) removeLeast(reason cache.EvictReason) {
+	if c.head != nil {
+		c.removeItem(c.items[c.head.entries.Back().Key], reason)
+	}
+}
+
+// removeItem is used to remove a given item from the cache
+func (c *BucketLFU[K, V]) removeItem(it *bucketItem[K, V], reason cache.EvictReason) {
+	it.bucket.entries.Remove(&it.Entry)
+	if it.bucket.entries.Length() == 0 {
+		c.removeBucket(it.bucket)
+	}
+	delete(c.items, it.Key)
+	c.stats.Evicted(reason, 1)
+	if c.onEvict != nil {
+		c.onEvict(it.Key, it.Value)
+	}
+}
+
+// entries returns the entries from the next to be evicted to the last.
+func (c *BucketLFU[K, V]) entries(reverse bool) []*internal.Entry[K, V] {
+	entries := make([]*internal.Entry[K, V], 0, len(c.items))
+	for b := c.head; b != nil; b = b.next {
+		for e := b.entries.Back(); e != nil; e = e.PrevEntry() {
+			entries = append(entries, e)
+		}
+	}
+	if reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	return entries
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be
+// evicted to the last.
+func (c *BucketLFU[K, V]) Keys(reverse bool) []K {
+	entries := c.entries(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, in the same order as
+// Keys.
+func (c *BucketLFU[K, V]) Values(reverse bool) []V {
+	entries := c.entries(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Value
+	}
+	return values
+}
+
+// Len returns the number of items in the cache.
+func (c *BucketLFU[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Cap returns the size of the cache.
+func (c *BucketLFU[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache.
+func (c *BucketLFU[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, it := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, it.Value)
+		}
+		delete(c.items, k)
+	}
+	c.head = nil
+}
+
+// Resize changes the cache size.
+func (c *BucketLFU[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	for len(c.items) > size {
+		c.removeLeast(cache.EvictReasonCapacity)
+		evicted++
+	}
+	c.size = size
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *BucketLFU[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *BucketLFU[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
@@ -0,0 +1,89 @@
+package lfu
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBucketLFU(t *testing.T) {
+	var evicted []int
+	c, err := NewBucketLFU[int, int](3, func(k, _ int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Add(2, 2)
+	c.Add(3, 3)
+	c.Get(1)
+	c.Get(1)
+	c.Get(3)
+
+	// 2 has the lowest count
+	if !c.Add(4, 4) || c.Contains(2) {
+		t.Fatalf("2 should be evicted: %v", c.Keys(false))
+	}
+	if keys := fmt.Sprint(c.Keys(false)); keys != "[4 3 1]" {
+		t.Fatalf("Keys(false) = %s", keys)
+	}
+	if values := fmt.Sprint(c.Values(true)); values != "[1 3 4]" {
+		t.Fatalf("Values(true) = %s", values)
+	}
+
+	// ties are broken by recency, 4 and 3 both have a count of 2
+	c.Get(4)
+	c.Peek(3)
+	if !c.Add(5, 5) || c.Contains(3) {
+		t.Fatalf("3 should be evicted: %v", c.Keys(false))
+	}
+	if keys := fmt.Sprint(c.Keys(false)); keys != "[5 4 1]" {
+		t.Fatalf("Keys(false) = %s", keys)
+	}
+
+	if !c.Remove(1) || c.Remove(1) {
+		t.Fatalf("bad Remove")
+	}
+	if n, err := c.Resize(1); err != nil || n != 1 || !c.Contains(4) {
+		t.Fatalf("Resize(1) = %d, %v: %v", n, err, c.Keys(false))
+	}
+	c.Purge()
+	if c.Len() != 0 || len(c.Keys(false)) != 0 {
+		t.Fatalf("bad Purge: %v", c.Keys(false))
+	}
+	if got := fmt.Sprint(evicted); got != "[2 3 1 5 4]" {
+		t.Fatalf("evicted %s", got)
+	}
+}
+
+const benchEntries = 1 << 20
+
+func BenchmarkLFU_Hit(b *testing.B) {
+	c, err := NewLFU[int, int](benchEntries, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	benchmarkHit(b, c.Add, c.Get)
+}
+
+func BenchmarkBucketLFU_Hit(b *testing.B) {
+	c, err := NewBucketLFU[int, int](benchEntries, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	benchmarkHit(b, c.Add, c.Get)
+}
+
+// benchmarkHit fills a cache of benchEntries and measures Get hits.
+func benchmarkHit(b *testing.B, add func(int, int) bool, get func(int) (int, bool)) {
+	for i := 0; i < benchEntries; i++ {
+		add(i, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := get((i * 7919) % benchEntries); !ok {
+			b.Fatalf("miss")
+		}
+	}
+}
@@ -3,57 +3,177 @@ package lfu
 import (
 	"container/heap"
 	"errors"
+	"fast-cache/cache"
+	"fmt"
+	"io"
+	"sort"
+	"time"
 )
 
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback[K comparable, V any] func(key K, value V)
 
+var _ cache.Cache[int, int] = (*LFU[int, int])(nil)
+var _ cache.StatsProvider = (*LFU[int, int])(nil)
+var _ cache.CapacityProvider = (*LFU[int, int])(nil)
+var _ cache.Snapshotter = (*LFU[int, int])(nil)
+
+// LFU implements a non-thread safe fixed size LFU cache
 type LFU[K comparable, V any] struct {
 	size      int
 	evictList *PriorityQueue[K, V]
 	items     map[K]*PqEntry[K, V]
 	onEvict   EvictCallback[K, V]
+	stats     *cache.StatsCounter
+	admit     cache.Admitter[K]
+	codec     cache.Codec[K, V]
+
+	// coster is set in cost mode, the cache then holds at most maxCost and
+	// cost is the total of the entries.
+	coster  cache.Coster[K, V]
+	cost    int64
+	maxCost int64
+
+	// aging decays the reference counts, age is the LFU-DA cache age and
+	// ops counts operations since the counts were last halved.
+	aging Aging
+	age   int
+	ops   int
+
+	// now stamps references, it breaks ties between equal priorities.
+	now func() time.Time
+}
+
+// Aging configures how the reference counts of an LFU decay, so keys that
+// were hot once do not stay cached forever. The zero value never decays.
+type Aging struct {
+	// HalveEvery halves every reference count after that many Add and Get
+	// calls, zero disables halving.
+	HalveEvery int
+
+	// Dynamic enables LFU-DA: the cache age is raised to the priority of
+	// every evicted entry and added to the count of referenced entries.
+	Dynamic bool
+
+	// MaxCount caps the reference counts, zero means no cap.
+	MaxCount int
 }
 
 // NewLFU NewLRU constructs an LRU of the given size
 func NewLFU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LFU[K, V], error) {
+	return NewLFUParams[K, V](size, Aging{}, onEvict)
+}
+
+// NewLFUParams constructs an LFU of the given size whose reference counts
+// decay as configured by aging.
+func NewLFUParams[K comparable, V any](size int, aging Aging, onEvict EvictCallback[K, V]) (*LFU[K, V], error) {
 	if size <= 0 {
 		return nil, errors.New("must provide a positive size")
 	}
+	if aging.HalveEvery < 0 || aging.MaxCount < 0 {
+		return nil, errors.New("invalid aging")
+	}
 
 	c := &LFU[K, V]{
 		size:      size,
 		evictList: NewPriorityQueue[K, V](size),
 		items:     make(map[K]*PqEntry[K, V], size),
 		onEvict:   onEvict,
+		aging:     aging,
+		now:       time.Now,
+	}
+	return c, nil
+}
+
+// NewLFUWithCost constructs an LFU bounded by the total cost of its entries
+// as returned by coster, rather than by their count.
+func NewLFUWithCost[K comparable, V any](maxCost int64, coster cache.Coster[K, V], onEvict EvictCallback[K, V]) (*LFU[K, V], error) {
+	if maxCost <= 0 {
+		return nil, errors.New("must provide a positive max cost")
+	}
+	if coster == nil {
+		return nil, errors.New("must provide a coster")
+	}
+
+	c := &LFU[K, V]{
+		evictList: NewPriorityQueue[K, V](0),
+		items:     make(map[K]*PqEntry[K, V]),
+		onEvict:   onEvict,
+		coster:    coster,
+		maxCost:   maxCost,
+		now:       time.Now,
 	}
 	return c, nil
 }
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
+// In cost mode an entry costing more than the max cost is not cached, and
+// an older value of its key is removed.
 func (c *LFU[K, V]) Add(key K, value V) (evicted bool) {
+	c.tick()
+	c.admit.Record(key)
+	var cost int64
+	if c.coster != nil {
+		if cost = c.coster(key, value); cost < 0 || cost > c.maxCost {
+			c.Remove(key)
+			return false
+		}
+	}
+
 	// Check for existing item
 	if ent, ok := c.items[key]; ok {
-		c.evictList.update(ent, value)
+		ent.Val = value
+		c.reference(ent)
+		c.cost += cost - ent.cost
+		ent.cost = cost
+		c.stats.Updated()
+		for c.coster != nil && c.cost > c.maxCost {
+			c.removeElement()
+			evicted = true
+		}
+		return evicted
+	}
+	if c.admit.Enabled() && c.full(cost) && !c.admit.Admit(key, (*c.evictList)[0].Key) {
 		return false
 	}
-	evict := c.evictList.Len() == c.size
-	if evict {
+	for c.coster != nil && c.cost+cost > c.maxCost {
+		c.removeElement()
+		evicted = true
+	}
+	if c.coster == nil && c.evictList.Len() == c.size {
 		c.removeElement()
+		evicted = true
 	}
 
-	e := newEntry(key, value)
+	e := newEntry(key, value, c.now())
+	e.cost = cost
+	e.age = c.age
 	heap.Push(c.evictList, e)
 	c.items[key] = e
+	c.cost += cost
+	c.stats.Added()
 
-	return evict
+	return evicted
+}
+
+// full returns if adding an entry of the given cost requires an eviction.
+func (c *LFU[K, V]) full(cost int64) bool {
+	if c.coster != nil {
+		return c.evictList.Len() > 0 && c.cost+cost > c.maxCost
+	}
+	return c.evictList.Len() == c.size
 }
 
 // removeElement is used to remove a given list element from the cache
 func (c *LFU[K, V]) removeElement() {
 	ent := heap.Pop(c.evictList)
 	if ent != nil {
+		if c.aging.Dynamic {
+			c.age = ent.(*PqEntry[K, V]).priority()
+		}
 		delete(c.items, ent.(*PqEntry[K, V]).Key)
+		c.cost -= ent.(*PqEntry[K, V]).cost
+		c.stats.Evicted(cache.EvictReasonCapacity, 1)
 		if c.onEvict != nil {
 			c.onEvict(ent.(*PqEntry[K, V]).Key, ent.(*PqEntry[K, V]).Val)
 		}
@@ -62,9 +182,43 @@ func (c *LFU[K, V]) removeElement() {
 
 // Get looks up a key's value from the cache.
 func (c *LFU[K, V]) Get(key K) (value V, ok bool) {
+	c.tick()
+	c.admit.Record(key)
+	if e, ok := c.items[key]; ok {
+		c.reference(e)
+		c.stats.Hit()
+		return e.Val, true
+	}
+	c.stats.Miss()
+	return
+}
+
+// reference records a reference to e.
+func (c *LFU[K, V]) reference(e *PqEntry[K, V]) {
+	e.referenced(c.age, c.aging.MaxCount, c.now())
+	heap.Fix(c.evictList, e.index)
+}
+
+// tick counts an operation, halving every reference count once
+// aging.HalveEvery operations are reached.
+func (c *LFU[K, V]) tick() {
+	if c.aging.HalveEvery == 0 {
+		return
+	}
+	if c.ops++; c.ops < c.aging.HalveEvery {
+		return
+	}
+	c.ops = 0
+	for _, e := range *c.evictList {
+		e.referenceCount /= 2
+	}
+	heap.Init(c.evictList)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the reference count of the key.
+func (c *LFU[K, V]) Peek(key K) (value V, ok bool) {
 	if e, ok := c.items[key]; ok {
-		e.referenced()
-		heap.Fix(c.evictList, e.index)
 		return e.Val, true
 	}
 	return
@@ -83,37 +237,45 @@ func (c *LFU[K, V]) Remove(key K) (present bool) {
 	if ent, ok := c.items[key]; ok {
 		heap.Remove(c.evictList, ent.index)
 		delete(c.items, key)
+		c.cost -= ent.cost
+		c.stats.Evicted(cache.EvictReasonRemoved, 1)
 		return true
 	}
 	return false
 }
 
-// Keys returns a slice of the keys in the cache, from oldest to newest.
-func (c *LFU[K, V]) Keys(reverse bool) []K {
-	keys := make([]K, len(c.items))
-	if reverse == true {
-		for index, ent := range *c.evictList {
-			keys[index] = ent.Key
-		}
-	} else {
-		for i := c.evictList.Len() - 1; i >= 0; i-- {
-			keys[i] = (*c.evictList)[i].Key
+// entries returns a copy of the entries sorted from the next to be evicted
+// to the last, the other way around if reverse.
+func (c *LFU[K, V]) entries(reverse bool) []*PqEntry[K, V] {
+	entries := make(PriorityQueue[K, V], len(*c.evictList))
+	copy(entries, *c.evictList)
+	sort.Slice(entries, func(i, j int) bool {
+		if reverse {
+			return entries.Less(j, i)
 		}
+		return entries.Less(i, j)
+	})
+	return entries
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be evicted
+// to the last.
+func (c *LFU[K, V]) Keys(reverse bool) []K {
+	entries := c.entries(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
 	}
 	return keys
 }
 
-// Values returns a slice of the values in the cache, from oldest to newest.
+// Values returns a slice of the values in the cache, in the same order as
+// Keys.
 func (c *LFU[K, V]) Values(reverse bool) []V {
-	values := make([]V, len(c.items))
-	if reverse == true {
-		for index, ent := range *c.evictList {
-			values[index] = ent.Val
-		}
-	} else {
-		for i := c.evictList.Len() - 1; i >= 0; i-- {
-			values[i] = (*c.evictList)[i].Val
-		}
+	entries := c.entries(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Val
 	}
 	return values
 }
@@ -122,3 +284,146 @@ func (c *LFU[K, V]) Values(reverse bool) []V {
 func (c *LFU[K, V]) Len() int {
 	return c.evictList.Len()
 }
+
+// Cap returns the size of the cache, its max cost in cost mode.
+func (c *LFU[K, V]) Cap() int {
+	if c.coster != nil {
+		return int(c.maxCost)
+	}
+	return c.size
+}
+
+// Purge is used to completely clear the cache.
+func (c *LFU[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, e := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, e.Val)
+		}
+		delete(c.items, k)
+	}
+	c.evictList = NewPriorityQueue[K, V](c.size)
+	c.cost = 0
+	c.age, c.ops = 0, 0
+}
+
+// Resize changes the cache size, in cost units in cost mode.
+func (c *LFU[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	if c.coster != nil {
+		c.maxCost = int64(size)
+		for c.cost > c.maxCost {
+			c.removeElement()
+			evicted++
+		}
+		return evicted, nil
+	}
+	diff := c.Len() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for i := 0; i < diff; i++ {
+		c.removeElement()
+	}
+	c.size = size
+	return diff, nil
+}
+
+// Cost returns the total cost of the entries in cost mode, zero otherwise.
+func (c *LFU[K, V]) Cost() int64 {
+	return c.cost
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *LFU[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *LFU[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// SetAdmission installs the admission policy consulted before a new key
+// evicts an entry, nil admits every key.
+func (c *LFU[K, V]) SetAdmission(admission cache.Admission[K]) {
+	c.admit.Set(admission)
+}
+
+// SetCodec sets the codec of Snapshot and Restore, nil is cache.GobCodec.
+func (c *LFU[K, V]) SetCodec(codec cache.Codec[K, V]) {
+	c.codec = codec
+}
+
+// Snapshot writes the entries of the cache to w with their reference
+// counts, and the state of the aging.
+func (c *LFU[K, V]) Snapshot(w io.Writer) error {
+	s := &cache.Snapshot[K, V]{
+		Policy:  "lfu",
+		State:   []int64{int64(c.age), int64(c.ops)},
+		Entries: make([]cache.SnapshotEntry[K, V], 0, len(c.items)),
+	}
+	for _, e := range *c.evictList {
+		s.Entries = append(s.Entries, cache.SnapshotEntry[K, V]{
+			Key:          e.Key,
+			Value:        e.Val,
+			Count:        e.referenceCount,
+			Age:          e.age,
+			ReferencedAt: e.referencedAt,
+		})
+	}
+	return cache.WriteSnapshot(w, c.codec, s)
+}
+
+// Restore purges the cache and loads a snapshot written by Snapshot, with
+// the same reference counts. Entries that do not fit are evicted as usual,
+// least frequently used first.
+func (c *LFU[K, V]) Restore(r io.Reader) error {
+	s, err := cache.ReadSnapshot(r, "lfu", c.codec)
+	if err != nil {
+		return err
+	}
+	if len(s.State) != 2 {
+		return fmt.Errorf("%w: bad lfu state", cache.ErrInvalidSnapshot)
+	}
+	c.Purge()
+	c.age, c.ops = int(s.State[0]), int(s.State[1])
+	for _, se := range s.Entries {
+		var cost int64
+		if c.coster != nil {
+			if cost = c.coster(se.Key, se.Value); cost < 0 || cost > c.maxCost {
+				continue
+			}
+		}
+		if _, ok := c.items[se.Key]; ok {
+			continue
+		}
+		e := &PqEntry[K, V]{
+			Key:            se.Key,
+			Val:            se.Value,
+			referenceCount: se.Count,
+			referencedAt:   se.ReferencedAt,
+			cost:           cost,
+			age:            se.Age,
+		}
+		*c.evictList = append(*c.evictList, e)
+		c.items[se.Key] = e
+		c.cost += cost
+	}
+	for i, e := range *c.evictList {
+		e.index = i
+	}
+	heap.Init(c.evictList)
+	for c.coster != nil && c.cost > c.maxCost {
+		c.removeElement()
+	}
+	for c.coster == nil && c.evictList.Len() > c.size {
+		c.removeElement()
+	}
+	return nil
+}
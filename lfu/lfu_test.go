@@ -1,8 +1,10 @@
 package lfu
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestSet(t *testing.T) {
@@ -110,3 +112,165 @@ func TestIssue33(t *testing.T) {
 	cache.Remove("foo3")
 	fmt.Println(cache.Keys(false))
 }
+
+func TestLFUWithCost(t *testing.T) {
+	cache, err := NewLFUWithCost[string, string](10, func(_ string, v string) int64 {
+		return int64(len(v))
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cache.Add("a", "aaaa")
+	cache.Add("b", "bbbb")
+	cache.Get("a")
+	if !cache.Add("c", "cccc") {
+		t.Fatalf("Add should evict")
+	}
+	if cache.Contains("b") || !cache.Contains("a") || cache.Cost() != 8 {
+		t.Fatalf("bad eviction: %v, cost %d", cache.Keys(false), cache.Cost())
+	}
+
+	// too large entries are rejected and drop the old value
+	if cache.Add("a", "aaaaaaaaaaa") || cache.Contains("a") || cache.Cost() != 4 {
+		t.Fatalf("oversized entry cached: %v, cost %d", cache.Keys(false), cache.Cost())
+	}
+
+	cache.Add("d", "dddddd")
+	if n, err := cache.Resize(6); err != nil || n != 1 {
+		t.Fatalf("Resize(6) = %d, %v", n, err)
+	}
+	if cache.Cost() != 6 || cache.Len() != 1 {
+		t.Fatalf("bad resize: %v, cost %d", cache.Keys(false), cache.Cost())
+	}
+}
+
+// hotThenScan references "hot" often, then adds n keys referenced once.
+func hotThenScan(t *testing.T, aging Aging, n int) *LFU[string, int] {
+	t.Helper()
+	cache, err := NewLFUParams[string, int](2, aging, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cache.Add("hot", 0)
+	for i := 0; i < 8; i++ {
+		cache.Get("hot")
+	}
+	for i := 0; i < n; i++ {
+		cache.Add(fmt.Sprint(i), i)
+	}
+	return cache
+}
+
+func TestLFUAging(t *testing.T) {
+	if cache := hotThenScan(t, Aging{}, 50); !cache.Contains("hot") {
+		t.Fatalf("hot should stay without aging: %v", cache.Keys(false))
+	}
+	if cache := hotThenScan(t, Aging{HalveEvery: 10}, 50); cache.Contains("hot") {
+		t.Fatalf("hot should decay with halving: %v", cache.Keys(false))
+	}
+	if cache := hotThenScan(t, Aging{Dynamic: true}, 50); cache.Contains("hot") {
+		t.Fatalf("hot should age out with LFU-DA: %v", cache.Keys(false))
+	}
+	if _, err := NewLFUParams[string, int](2, Aging{HalveEvery: -1}, nil); err == nil {
+		t.Fatalf("negative HalveEvery should fail")
+	}
+}
+
+func TestLFUMaxCount(t *testing.T) {
+	cache, err := NewLFUParams[string, int](2, Aging{MaxCount: 2}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time { return now }
+	cache.Add("a", 1)
+	for i := 0; i < 10; i++ {
+		cache.Get("a")
+	}
+	now = now.Add(time.Second)
+	cache.Add("b", 2)
+	cache.Get("b")
+
+	// both counts are capped to 2, a was referenced least recently
+	if !cache.Add("c", 3) || cache.Contains("a") || !cache.Contains("b") {
+		t.Fatalf("a should be evicted: %v", cache.Keys(false))
+	}
+}
+
+func TestLFUKeysOrder(t *testing.T) {
+	cache, err := NewLFU[string, int](5, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	for i, k := range []string{"a", "b", "c", "d", "e"} {
+		cache.Add(k, i)
+	}
+	for k, n := range map[string]int{"a": 1, "c": 2, "e": 3} {
+		for i := 0; i < n; i++ {
+			cache.Get(k)
+		}
+	}
+
+	// b and d tie on their count, b was referenced first
+	if got := fmt.Sprint(cache.Keys(false)); got != "[b d a c e]" {
+		t.Fatalf("bad keys: %s", got)
+	}
+	if got := fmt.Sprint(cache.Keys(true)); got != "[e c a d b]" {
+		t.Fatalf("bad reversed keys: %s", got)
+	}
+	if got := fmt.Sprint(cache.Values(false)); got != "[1 3 0 2 4]" {
+		t.Fatalf("bad values: %s", got)
+	}
+	if got := fmt.Sprint(cache.Values(true)); got != "[4 2 0 3 1]" {
+		t.Fatalf("bad reversed values: %s", got)
+	}
+
+	// the order is the eviction order
+	for _, want := range []string{"b", "d"} {
+		cache.Add(want+"'", 0)
+		if cache.Contains(want) {
+			t.Fatalf("%s should have been evicted: %v", want, cache.Keys(false))
+		}
+	}
+}
+
+func TestLFUWithCost_Negative(t *testing.T) {
+	negative := true
+	cache, err := NewLFUWithCost[string, int](10, func(k string, v int) int64 {
+		if negative && v < 0 {
+			return int64(v)
+		}
+		return 1
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// a negative cost would let the cache grow past its max cost
+	cache.Add("a", 1)
+	if cache.Add("a", -20) || cache.Contains("a") || cache.Cost() != 0 {
+		t.Fatalf("negative cost cached: %v, cost %d", cache.Keys(false), cache.Cost())
+	}
+
+	// restored entries are checked as well
+	negative = false
+	cache.Add("a", -1)
+	cache.Add("b", 2)
+	var buf bytes.Buffer
+	if err := cache.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	negative = true
+	if err := cache.Restore(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if cache.Contains("a") || !cache.Contains("b") || cache.Cost() != 1 {
+		t.Fatalf("negative cost restored: %v, cost %d", cache.Keys(false), cache.Cost())
+	}
+}
@@ -11,6 +11,10 @@ type PqEntry[K comparable, V any] struct {
 	Val            V
 	referenceCount int
 	referencedAt   time.Time
+	cost           int64
+	// age is the cache age at the last reference, zero unless the cache
+	// uses dynamic aging.
+	age int
 }
 
 //// GetReferenceCount gets reference count from cache value.
@@ -21,19 +25,30 @@ type PqEntry[K comparable, V any] struct {
 //	return 1
 //}
 
-func newEntry[K comparable, V any](key K, val V) *PqEntry[K, V] {
+func newEntry[K comparable, V any](key K, val V, now time.Time) *PqEntry[K, V] {
 	return &PqEntry[K, V]{
 		index:          0,
 		Key:            key,
 		Val:            val,
 		referenceCount: 1,
-		referencedAt:   time.Now(),
+		referencedAt:   now,
 	}
 }
 
-func (e *PqEntry[K, V]) referenced() {
+// referenced records a reference at the given cache age and time, capping
+// the reference count to maxCount unless it is zero.
+func (e *PqEntry[K, V]) referenced(age, maxCount int, now time.Time) {
 	e.referenceCount++
-	e.referencedAt = time.Now()
+	if maxCount > 0 && e.referenceCount > maxCount {
+		e.referenceCount = maxCount
+	}
+	e.age = age
+	e.referencedAt = now
+}
+
+// priority returns the eviction priority of e, lowest goes first.
+func (e *PqEntry[K, V]) priority() int {
+	return e.referenceCount + e.age
 }
 
 type PriorityQueue[K comparable, V any] []*PqEntry[K, V]
@@ -49,10 +64,10 @@ var _ heap.Interface = (*PriorityQueue[struct{}, interface{}])(nil)
 func (q PriorityQueue[K, V]) Len() int { return len(q) }
 
 func (q PriorityQueue[K, V]) Less(i, j int) bool {
-	if q[i].referenceCount == q[j].referenceCount {
-		return q[i].referencedAt.Before(q[j].referencedAt)
+	if pi, pj := q[i].priority(), q[j].priority(); pi != pj {
+		return pi < pj
 	}
-	return q[i].referenceCount < q[j].referenceCount
+	return q[i].referencedAt.Before(q[j].referencedAt)
 }
 
 func (q PriorityQueue[K, V]) Swap(i, j int) {
@@ -83,9 +98,3 @@ func (q *PriorityQueue[K, V]) Pop() interface{} {
 	*q = new
 	return entry
 }
-
-func (q *PriorityQueue[K, V]) update(e *PqEntry[K, V], val V) {
-	e.Val = val
-	e.referenced()
-	heap.Fix(q, e.index)
-}
@@ -0,0 +1,75 @@
+package lfu
+
+import (
+	"bytes"
+	"fast-cache/cache"
+	"reflect"
+	"testing"
+)
+
+func TestLFU_Snapshot(t *testing.T) {
+	c, err := NewLFUParams[int, int](3, Aging{Dynamic: true}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	codec, err := cache.NewBinaryCodec[int, int]()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.SetCodec(codec)
+	for i := 0; i < 4; i++ {
+		c.Add(i, i*10)
+		for j := 0; j < i; j++ {
+			c.Get(i)
+		}
+	}
+	var buf bytes.Buffer
+	if err := c.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	r, _ := NewLFUParams[int, int](3, Aging{Dynamic: true}, nil)
+	r.SetCodec(codec)
+	if err := r.Restore(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if r.age != c.age || r.Len() != 3 {
+		t.Fatalf("bad: age %d, len %d", r.age, r.Len())
+	}
+	for _, k := range c.Keys(false) {
+		if r.items[k].referenceCount != c.items[k].referenceCount || r.items[k].Val != c.items[k].Val {
+			t.Fatalf("bad entry %d: %+v", k, r.items[k])
+		}
+	}
+
+	// the reference counts decide the next victims alike
+	for i := 10; i < 13; i++ {
+		c.Add(i, i)
+		r.Add(i, i)
+		if !reflect.DeepEqual(r.Keys(false), c.Keys(false)) {
+			t.Fatalf("bad: %v, want %v", r.Keys(false), c.Keys(false))
+		}
+	}
+
+	// a smaller cache keeps the most referenced entries
+	buf.Reset()
+	if err := c.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var evicted []int
+	small, _ := NewLFUParams[int, int](1, Aging{Dynamic: true}, func(k, v int) {
+		evicted = append(evicted, k)
+	})
+	small.SetCodec(codec)
+	if err := small.Restore(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if small.Len() != 1 || len(evicted) != 2 {
+		t.Fatalf("bad: %v %v", small.Keys(false), evicted)
+	}
+	for _, k := range evicted {
+		if small.items[small.Keys(false)[0]].priority() < c.items[k].priority() {
+			t.Fatalf("kept %v over %d", small.Keys(false), k)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package lfu
+
+import (
+	"fast-cache/cache"
+	"fast-cache/workload/workloadtest"
+	"testing"
+)
+
+func BenchmarkLFU_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewLFU[uint64, uint64](size, nil)
+	})
+}
+
+func BenchmarkBucketLFU_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return NewBucketLFU[uint64, uint64](size, nil)
+	})
+}
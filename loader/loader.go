@@ -0,0 +1,196 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fast-cache/cache"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrLoadPanicked is returned, wrapping the panic value, to the callers of
+// a load whose LoaderFunc panicked. The panic is recovered so it does not
+// crash the process from the goroutine running the load.
+var ErrLoadPanicked = errors.New("loader: load function panicked")
+
+// minErrSweep is the number of cached errors kept before expired ones are
+// swept out.
+const minErrSweep = 64
+
+// LoaderFunc loads the value of a key missing from the cache.
+type LoaderFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Loader fills a cache on misses through a LoaderFunc. Concurrent misses
+// for the same key are coalesced so the backend sees a single call, and
+// failed loads may be remembered for a short negative TTL.
+//
+// The wrapped cache must be safe for concurrent use, e.g. synced.Cache,
+// sharded.Sharded or lru.TwoQueueCache.
+type Loader[K comparable, V any] struct {
+	c      cache.Cache[K, V]
+	load   LoaderFunc[K, V]
+	errTTL time.Duration
+	now    func() time.Time
+
+	lock       sync.Mutex
+	calls      map[K]*call[V]
+	errs       map[K]cachedErr
+	errSweepAt int
+}
+
+// call is an in-flight or completed load.
+type call[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// cachedErr is a failed load remembered until expiresAt.
+type cachedErr struct {
+	err       error
+	expiresAt time.Time
+}
+
+// Config configures a Loader, the zero value of a field selects its
+// default.
+type Config struct {
+	// Now is the time source of the error TTL, nil means time.Now.
+	Now func() time.Time
+}
+
+// New creates a Loader over c that does not cache errors.
+func New[K comparable, V any](c cache.Cache[K, V], load LoaderFunc[K, V]) (*Loader[K, V], error) {
+	return NewParams[K, V](c, load, 0, Config{})
+}
+
+// NewParams creates a Loader over c that caches load errors for errTTL,
+// a zero errTTL disables negative caching.
+func NewParams[K comparable, V any](c cache.Cache[K, V], load LoaderFunc[K, V], errTTL time.Duration, config Config) (*Loader[K, V], error) {
+	if c == nil || load == nil {
+		return nil, errors.New("must provide a cache and a load function")
+	}
+	if errTTL < 0 {
+		return nil, errors.New("invalid error ttl")
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	l := &Loader[K, V]{
+		c:          c,
+		load:       load,
+		errTTL:     errTTL,
+		now:        config.Now,
+		calls:      make(map[K]*call[V]),
+		errs:       make(map[K]cachedErr),
+		errSweepAt: minErrSweep,
+	}
+	return l, nil
+}
+
+// GetOrLoad returns key's value from the cache, loading and adding it on a
+// miss. Callers missing on a key that is already being loaded wait for that
+// load instead of starting their own. Every caller, the one starting the
+// load included, gives up when ctx is done.
+//
+// The load is shared and runs in its own goroutine, so it gets ctx's values
+// but not its cancellation or deadline, and the load function should bound
+// its own duration.
+func (l *Loader[K, V]) GetOrLoad(ctx context.Context, key K) (value V, err error) {
+	if v, ok := l.c.Get(key); ok {
+		return v, nil
+	}
+
+	l.lock.Lock()
+	// The value may have been loaded since the miss above
+	if v, ok := l.c.Peek(key); ok {
+		l.lock.Unlock()
+		return v, nil
+	}
+	if e, ok := l.errs[key]; ok {
+		if l.now().Before(e.expiresAt) {
+			l.lock.Unlock()
+			return value, e.err
+		}
+		delete(l.errs, key)
+	}
+	cl, ok := l.calls[key]
+	if !ok {
+		cl = &call[V]{done: make(chan struct{})}
+		l.calls[key] = cl
+		// The caller starting the load giving up must not fail the others
+		go l.doCall(context.WithoutCancel(ctx), key, cl)
+	}
+	l.lock.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.val, cl.err
+	case <-ctx.Done():
+		return value, ctx.Err()
+	}
+}
+
+// doCall runs the load for key and publishes its result to waiters.
+func (l *Loader[K, V]) doCall(ctx context.Context, key K, cl *call[V]) {
+	defer func() {
+		if r := recover(); r != nil {
+			cl.err = fmt.Errorf("%w: %v", ErrLoadPanicked, r)
+		}
+		l.lock.Lock()
+		if cl.err != nil && l.errTTL > 0 && !isContextErr(cl.err) {
+			l.storeErr(key, cl.err)
+		}
+		delete(l.calls, key)
+		l.lock.Unlock()
+		close(cl.done)
+	}()
+
+	cl.val, cl.err = l.load(ctx, key)
+	if cl.err == nil {
+		// Add before the call is dropped so later misses find the value
+		l.c.Add(key, cl.val)
+	}
+}
+
+// isContextErr reports whether err comes from a canceled or expired
+// context, which says nothing about the key and is never cached.
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// storeErr remembers a failed load, sweeping expired errors once the
+// table has doubled since the last sweep.
+func (l *Loader[K, V]) storeErr(key K, err error) {
+	now := l.now()
+	if len(l.errs) >= l.errSweepAt {
+		for k, e := range l.errs {
+			if !now.Before(e.expiresAt) {
+				delete(l.errs, k)
+			}
+		}
+		l.errSweepAt = 2 * len(l.errs)
+		if l.errSweepAt < minErrSweep {
+			l.errSweepAt = minErrSweep
+		}
+	}
+	l.errs[key] = cachedErr{err: err, expiresAt: now.Add(l.errTTL)}
+}
+
+// Remove removes the provided key from the cache and forgets a cached
+// error for it, returning if the key was contained.
+func (l *Loader[K, V]) Remove(key K) (present bool) {
+	l.lock.Lock()
+	delete(l.errs, key)
+	l.lock.Unlock()
+	return l.c.Remove(key)
+}
+
+// Purge is used to completely clear the cache and the cached errors.
+func (l *Loader[K, V]) Purge() {
+	l.lock.Lock()
+	l.errs = make(map[K]cachedErr)
+	l.errSweepAt = minErrSweep
+	l.lock.Unlock()
+	l.c.Purge()
+}
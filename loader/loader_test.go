@@ -0,0 +1,211 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fast-cache/lru"
+	"fast-cache/synced"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad_Coalesce(t *testing.T) {
+	c, err := lru.New2Q[int, int](16)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var calls atomic.Int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	l, err := New[int, int](c, func(ctx context.Context, key int) (int, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return key * 10, nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := l.GetOrLoad(context.Background(), 1); err != nil || v != 10 {
+				t.Errorf("bad load: %v, %v", v, err)
+			}
+		}()
+	}
+	<-started
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("backend should be called once, got %d", got)
+	}
+	if v, ok := c.Get(1); !ok || v != 10 {
+		t.Fatalf("value should have been cached: %v, %v", v, ok)
+	}
+}
+
+func TestGetOrLoad_ErrorTTL(t *testing.T) {
+	c, err := synced.NewLRU[string, int](16, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	errBackend := errors.New("backend down")
+	var calls atomic.Int64
+	now := time.Unix(0, 0)
+	l, err := NewParams[string, int](c, func(ctx context.Context, key string) (int, error) {
+		if calls.Add(1) == 1 {
+			return 0, errBackend
+		}
+		return 1, nil
+	}, 20*time.Millisecond, Config{Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := l.GetOrLoad(context.Background(), "a"); err != errBackend {
+			t.Fatalf("want cached error, got %v", err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("error should have been cached, got %d calls", got)
+	}
+
+	now = now.Add(19 * time.Millisecond)
+	if _, err := l.GetOrLoad(context.Background(), "a"); err != errBackend {
+		t.Fatalf("want cached error before the ttl, got %v", err)
+	}
+	now = now.Add(time.Millisecond)
+	if v, err := l.GetOrLoad(context.Background(), "a"); err != nil || v != 1 {
+		t.Fatalf("bad load after error ttl: %v, %v", v, err)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("bad len: %d", c.Len())
+	}
+}
+
+func TestGetOrLoad_ContextCanceled(t *testing.T) {
+	c, err := synced.NewLRU[int, int](16, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	l, err := New[int, int](c, func(ctx context.Context, key int) (int, error) {
+		close(started)
+		<-release
+		return key, nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.GetOrLoad(context.Background(), 1)
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.GetOrLoad(ctx, 1); err != context.Canceled {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	close(release)
+	<-done
+}
+
+func TestGetOrLoad_LoaderCanceled(t *testing.T) {
+	c, err := synced.NewLRU[int, int](16, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	l, err := NewParams[int, int](c, func(ctx context.Context, key int) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return key, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}, time.Minute, Config{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// the caller starting the load gives up, the load goes on for the others
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := l.GetOrLoad(ctx, 1); err != context.Canceled {
+			t.Errorf("want context.Canceled, got %v", err)
+		}
+	}()
+	<-started
+	cancel()
+	<-done
+	waited := make(chan struct{})
+	go func() {
+		defer close(waited)
+		if v, err := l.GetOrLoad(context.Background(), 1); err != nil || v != 1 {
+			t.Errorf("bad load: %v, %v", v, err)
+		}
+	}()
+	close(release)
+	<-waited
+	if v, ok := c.Get(1); !ok || v != 1 {
+		t.Fatalf("value should have been cached: %v, %v", v, ok)
+	}
+
+	// context errors of the load itself are not cached
+	l, err = NewParams[int, int](c, func(ctx context.Context, key int) (int, error) {
+		return 0, context.DeadlineExceeded
+	}, time.Minute, Config{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := l.GetOrLoad(context.Background(), 2); err != context.DeadlineExceeded {
+		t.Fatalf("want context.DeadlineExceeded, got %v", err)
+	}
+	if len(l.errs) != 0 {
+		t.Fatalf("context error should not be cached: %v", l.errs)
+	}
+}
+
+func TestGetOrLoad_Panic(t *testing.T) {
+	c, err := synced.NewLRU[int, int](16, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l, err := New[int, int](c, func(ctx context.Context, key int) (int, error) {
+		if key == 1 {
+			panic("boom")
+		}
+		return key, nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := l.GetOrLoad(context.Background(), 1); !errors.Is(err, ErrLoadPanicked) {
+		t.Fatalf("want ErrLoadPanicked, got %v", err)
+	}
+	if c.Contains(1) || len(l.calls) != 0 {
+		t.Fatalf("panicked load left state behind: %v, %v", c.Keys(false), l.calls)
+	}
+	if v, err := l.GetOrLoad(context.Background(), 2); err != nil || v != 2 {
+		t.Fatalf("bad load: %v, %v", v, err)
+	}
+}
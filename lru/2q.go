@@ -2,6 +2,9 @@ package lru
 
 import (
 	"errors"
+	"fast-cache/cache"
+	"fmt"
+	"io"
 	"sync"
 )
 
@@ -15,6 +18,18 @@ const (
 	Default2QGhostEntries = 0.50
 )
 
+var _ cache.Cache[int, int] = (*TwoQueueCache[int, int])(nil)
+var _ cache.StatsProvider = (*TwoQueueCache[int, int])(nil)
+var _ cache.CapacityProvider = (*TwoQueueCache[int, int])(nil)
+var _ cache.Snapshotter = (*TwoQueueCache[int, int])(nil)
+
+// Segments of the entries of a TwoQueueCache snapshot.
+const (
+	twoQueueRecent uint8 = iota
+	twoQueueFrequent
+	twoQueueGhost
+)
+
 // TwoQueueCache is a thread-safe fixed size 2Q cache.
 // 2Q is an enhancement over the standard LRU cache
 // in that it tracks both frequently and recently used
@@ -33,6 +48,8 @@ type TwoQueueCache[K comparable, V any] struct {
 	recent      Cache[K, V]
 	frequent    Cache[K, V]
 	recentEvict Cache[K, struct{}]
+	stats       *cache.StatsCounter
+	codec       cache.Codec[K, V]
 	lock        sync.RWMutex
 }
 
@@ -93,6 +110,7 @@ func (c *TwoQueueCache[K, V]) Get(key K) (value V, ok bool) {
 
 	// Check if this is a frequent value
 	if val, ok := c.frequent.Get(key); ok {
+		c.stats.Hit()
 		return val, ok
 	}
 
@@ -101,15 +119,18 @@ func (c *TwoQueueCache[K, V]) Get(key K) (value V, ok bool) {
 	if val, ok := c.recent.Peek(key); ok {
 		c.recent.Remove(key)
 		c.frequent.Add(key, val)
+		c.stats.Hit()
+		c.stats.Promoted()
 		return val, ok
 	}
 
 	// No hit
+	c.stats.Miss()
 	return
 }
 
-// Add adds a value to the cache.
-func (c *TwoQueueCache[K, V]) Add(key K, value V) {
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *TwoQueueCache[K, V]) Add(key K, value V) (evicted bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -117,7 +138,8 @@ func (c *TwoQueueCache[K, V]) Add(key K, value V) {
 	// and just update the value
 	if c.frequent.Contains(key) {
 		c.frequent.Add(key, value)
-		return
+		c.stats.Updated()
+		return false
 	}
 
 	// Check if the value is recently used, and promote
@@ -125,30 +147,37 @@ func (c *TwoQueueCache[K, V]) Add(key K, value V) {
 	if c.recent.Contains(key) {
 		c.recent.Remove(key)
 		c.frequent.Add(key, value)
-		return
+		c.stats.Updated()
+		c.stats.Promoted()
+		return false
 	}
 
 	// If the value was recently evicted, add it to the
 	// frequently used list
 	if c.recentEvict.Contains(key) {
-		c.ensureSpace(true)
+		evicted = c.ensureSpace(true)
 		c.recentEvict.Remove(key)
 		c.frequent.Add(key, value)
-		return
+		c.stats.GhostHit()
+		c.stats.Added()
+		return evicted
 	}
 
 	// Add to the recently seen list
-	c.ensureSpace(false)
+	evicted = c.ensureSpace(false)
 	c.recent.Add(key, value)
+	c.stats.Added()
+	return evicted
 }
 
-// ensureSpace is used to ensure we have space in the cache
-func (c *TwoQueueCache[K, V]) ensureSpace(recentEvict bool) {
+// ensureSpace is used to ensure we have space in the cache.
+// Returns true if an entry was evicted.
+func (c *TwoQueueCache[K, V]) ensureSpace(recentEvict bool) bool {
 	// If we have space, nothing to do
 	recentLen := c.recent.Len()
 	freqLen := c.frequent.Len()
 	if recentLen+freqLen < c.size {
-		return
+		return false
 	}
 
 	// If the recent buffer is larger than
@@ -156,11 +185,16 @@ func (c *TwoQueueCache[K, V]) ensureSpace(recentEvict bool) {
 	if recentLen > 0 && (recentLen > c.recentSize || (recentLen == c.recentSize && !recentEvict)) {
 		k, _, _ := c.recent.RemoveOldest()
 		c.recentEvict.Add(k, struct{}{})
-		return
+		c.stats.Evicted(cache.EvictReasonCapacity, 1)
+		return true
 	}
 
 	// Remove from the frequent list otherwise
-	c.frequent.RemoveOldest()
+	_, _, ok := c.frequent.RemoveOldest()
+	if ok {
+		c.stats.Evicted(cache.EvictReasonCapacity, 1)
+	}
+	return ok
 }
 
 // Len returns the number of items in the cache.
@@ -170,6 +204,13 @@ func (c *TwoQueueCache[K, V]) Len() int {
 	return c.recent.Len() + c.frequent.Len()
 }
 
+// Cap returns the size of the cache.
+func (c *TwoQueueCache[K, V]) Cap() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.size
+}
+
 // Resize changes the cache size.
 func (c *TwoQueueCache[K, V]) Resize(size int) (evicted int, err error) {
 	c.lock.Lock()
@@ -219,25 +260,24 @@ func (c *TwoQueueCache[K, V]) Values(reverse bool) []V {
 	return append(v1, v2...)
 }
 
-// Remove removes the provided key from the cache.
-func (c *TwoQueueCache[K, V]) Remove(key K) {
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *TwoQueueCache[K, V]) Remove(key K) (present bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	if c.frequent.Remove(key) {
-		return
-	}
-	if c.recent.Remove(key) {
-		return
-	}
-	if c.recentEvict.Remove(key) {
-		return
+	if c.frequent.Remove(key) || c.recent.Remove(key) {
+		c.stats.Evicted(cache.EvictReasonRemoved, 1)
+		return true
 	}
+	c.recentEvict.Remove(key)
+	return false
 }
 
 // Purge is used to completely clear the cache.
 func (c *TwoQueueCache[K, V]) Purge() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	c.stats.Evicted(cache.EvictReasonPurged, c.recent.Len()+c.frequent.Len())
 	c.recent.Purge()
 	c.frequent.Purge()
 	c.recentEvict.Purge()
@@ -261,3 +301,86 @@ func (c *TwoQueueCache[K, V]) Peek(key K) (value V, ok bool) {
 	}
 	return c.recent.Peek(key)
 }
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *TwoQueueCache[K, V]) EnableStats() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *TwoQueueCache[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// SetCodec sets the codec of Snapshot and Restore, nil is cache.GobCodec.
+func (c *TwoQueueCache[K, V]) SetCodec(codec cache.Codec[K, V]) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.codec = codec
+}
+
+// Snapshot writes the entries of the cache to w: the ghost keys, the
+// recent entries then the frequent ones, each from oldest to newest.
+func (c *TwoQueueCache[K, V]) Snapshot(w io.Writer) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	s := &cache.Snapshot[K, V]{
+		Policy:  "2q",
+		Entries: make([]cache.SnapshotEntry[K, V], 0, c.recentEvict.Len()+c.recent.Len()+c.frequent.Len()),
+	}
+	for _, k := range c.recentEvict.Keys(false) {
+		s.Entries = append(s.Entries, cache.SnapshotEntry[K, V]{Key: k, Segment: twoQueueGhost})
+	}
+	for _, l := range []struct {
+		c       Cache[K, V]
+		segment uint8
+	}{{c.recent, twoQueueRecent}, {c.frequent, twoQueueFrequent}} {
+		keys, values := l.c.Keys(false), l.c.Values(false)
+		for i, k := range keys {
+			s.Entries = append(s.Entries, cache.SnapshotEntry[K, V]{Key: k, Value: values[i], Segment: l.segment})
+		}
+	}
+	return cache.WriteSnapshot(w, c.codec, s)
+}
+
+// Restore purges the cache and loads a snapshot written by Snapshot, with
+// the same recent, frequent and ghost lists. Entries that do not fit are
+// evicted as usual.
+func (c *TwoQueueCache[K, V]) Restore(r io.Reader) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s, err := cache.ReadSnapshot(r, "2q", c.codec)
+	if err != nil {
+		return err
+	}
+	for _, e := range s.Entries {
+		if e.Segment > twoQueueGhost {
+			return fmt.Errorf("%w: unknown 2q segment %d", cache.ErrInvalidSnapshot, e.Segment)
+		}
+	}
+	c.stats.Evicted(cache.EvictReasonPurged, c.recent.Len()+c.frequent.Len())
+	c.recent.Purge()
+	c.frequent.Purge()
+	c.recentEvict.Purge()
+	for _, e := range s.Entries {
+		switch e.Segment {
+		case twoQueueRecent:
+			c.frequent.Remove(e.Key)
+			c.recent.Add(e.Key, e.Value)
+		case twoQueueFrequent:
+			c.recent.Remove(e.Key)
+			c.frequent.Add(e.Key, e.Value)
+		case twoQueueGhost:
+			c.recentEvict.Add(e.Key, struct{}{})
+		}
+	}
+	for c.recent.Len()+c.frequent.Len() > c.size {
+		c.ensureSpace(true)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package lru
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+	"sync"
+	"time"
+)
+
+// numBuckets is the number of expiry buckets the default TTL is split into,
+// the background reaper cleans one bucket every ttl/numBuckets.
+const numBuckets = 100
+
+var _ cache.Cache[int, int] = (*Expirable[int, int])(nil)
+var _ cache.StatsProvider = (*Expirable[int, int])(nil)
+var _ cache.CapacityProvider = (*Expirable[int, int])(nil)
+
+// ExpirableEvictCallback is used to get a callback when a cache entry is
+// evicted, along with the reason it left the cache.
+type ExpirableEvictCallback[K comparable, V any] func(key K, value V, reason cache.EvictReason)
+
+// Expirable implements a thread-safe fixed size LRU cache whose entries
+// expire after a TTL. Expired entries are hidden from reads immediately and
+// reaped in time buckets by a background goroutine, call Close to stop it.
+type Expirable[K comparable, V any] struct {
+	size      int
+	ttl       time.Duration
+	interval  time.Duration
+	evictList *internal.LruList[K, V]
+	items     map[K]*internal.Entry[K, V]
+	onEvict   ExpirableEvictCallback[K, V]
+	stats     *cache.StatsCounter
+	now       func() time.Time
+
+	// buckets group entries by the reaper tick that expires them,
+	// nextCleanupBucket is the bucket the next tick cleans.
+	buckets           []bucket[K, V]
+	nextCleanupBucket uint8
+
+	lock      sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// ExpirableConfig configures an Expirable, the zero value of a field
+// selects its default.
+type ExpirableConfig struct {
+	// Now is the time source, nil means time.Now.
+	Now func() time.Time
+
+	// ManualReap disables the background reaper, expired entries are then
+	// only removed by Reap. They are hidden from reads either way.
+	ManualReap bool
+}
+
+// bucket is a container for holding entries to be expired
+type bucket[K comparable, V any] struct {
+	entries map[K]*internal.Entry[K, V]
+}
+
+// NewExpirable constructs an Expirable of the given size whose entries
+// expire after ttl unless added with AddWithTTL.
+func NewExpirable[K comparable, V any](size int, onEvict ExpirableEvictCallback[K, V], ttl time.Duration) (*Expirable[K, V], error) {
+	return NewExpirableParams[K, V](size, onEvict, ttl, ExpirableConfig{})
+}
+
+// NewExpirableParams constructs an Expirable like NewExpirable configured by
+// config.
+func NewExpirableParams[K comparable, V any](size int, onEvict ExpirableEvictCallback[K, V], ttl time.Duration, config ExpirableConfig) (*Expirable[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("must provide a positive ttl")
+	}
+
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	interval := ttl / numBuckets
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	c := &Expirable[K, V]{
+		size:      size,
+		ttl:       ttl,
+		interval:  interval,
+		evictList: internal.NewList[K, V](),
+		items:     make(map[K]*internal.Entry[K, V], size),
+		onEvict:   onEvict,
+		now:       config.Now,
+		buckets:   make([]bucket[K, V], numBuckets),
+		done:      make(chan struct{}),
+	}
+	for i := 0; i < numBuckets; i++ {
+		c.buckets[i] = bucket[K, V]{entries: make(map[K]*internal.Entry[K, V])}
+	}
+
+	if config.ManualReap {
+		return c, nil
+	}
+	go func(done <-chan struct{}) {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.Reap()
+			}
+		}
+	}(c.done)
+	return c, nil
+}
+
+// Close stops the background reaper. Entries are still hidden once expired.
+func (c *Expirable[K, V]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// Add adds a value to the cache using the default TTL.
+// Returns true if an eviction occurred.
+func (c *Expirable[K, V]) Add(key K, value V) (evicted bool) {
+	return c.AddWithTTL(key, value, c.ttl)
+}
+
+// AddWithTTL adds a value to the cache that expires after ttl, a
+// non-positive ttl means the entry never expires. Returns true if an
+// eviction occurred.
+func (c *Expirable[K, V]) AddWithTTL(key K, value V, ttl time.Duration) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	// Check for existing item
+	if ent, ok := c.items[key]; ok {
+		c.evictList.MoveToFront(ent)
+		c.removeFromBucket(ent)
+		ent.Value = value
+		ent.ExpiresAt = expiresAt
+		c.addToBucket(ent)
+		c.stats.Updated()
+		return false
+	}
+
+	// Add new item
+	ent := c.evictList.PushFrontExpirable(key, value, expiresAt)
+	c.items[key] = ent
+	c.addToBucket(ent)
+	c.stats.Added()
+
+	evict := c.evictList.Length() > c.size
+	// Verify size not exceeded
+	if evict {
+		c.removeOldest()
+	}
+	return evict
+}
+
+// Get looks up a key's value from the cache, expired entries are misses.
+func (c *Expirable[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ent, ok := c.items[key]; ok && !expired(ent, c.now()) {
+		c.evictList.MoveToFront(ent)
+		c.stats.Hit()
+		return ent.Value, true
+	}
+	c.stats.Miss()
+	return
+}
+
+// Contains checks if a non-expired key is in the cache, without updating
+// the recent-ness.
+func (c *Expirable[K, V]) Contains(key K) (ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ent, ok := c.items[key]
+	return ok && !expired(ent, c.now())
+}
+
+// Peek returns the key value (or undefined if not found or expired) without
+// updating the "recently used"-ness of the key.
+func (c *Expirable[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ent, ok := c.items[key]; ok && !expired(ent, c.now()) {
+		return ent.Value, true
+	}
+	return
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Expirable[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ent, ok := c.items[key]; ok {
+		c.removeElement(ent, cache.EvictReasonRemoved)
+		return true
+	}
+	return false
+}
+
+// Keys returns a slice of the non-expired keys in the cache, from oldest to newest.
+func (c *Expirable[K, V]) Keys(reverse bool) []K {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	keys := make([]K, 0, c.evictList.Length())
+	now := c.now()
+	if reverse == true {
+		for ent := c.evictList.Front(); ent != nil; ent = ent.NextEntry() {
+			if !expired(ent, now) {
+				keys = append(keys, ent.Key)
+			}
+		}
+	} else {
+		for ent := c.evictList.Back(); ent != nil; ent = ent.PrevEntry() {
+			if !expired(ent, now) {
+				keys = append(keys, ent.Key)
+			}
+		}
+	}
+	return keys
+}
+
+// Values returns a slice of the non-expired values in the cache, from oldest to newest.
+func (c *Expirable[K, V]) Values(reverse bool) []V {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	values := make([]V, 0, c.evictList.Length())
+	now := c.now()
+	if reverse == true {
+		for ent := c.evictList.Front(); ent != nil; ent = ent.NextEntry() {
+			if !expired(ent, now) {
+				values = append(values, ent.Value)
+			}
+		}
+	} else {
+		for ent := c.evictList.Back(); ent != nil; ent = ent.PrevEntry() {
+			if !expired(ent, now) {
+				values = append(values, ent.Value)
+			}
+		}
+	}
+	return values
+}
+
+// Len returns the number of items in the cache, including expired items
+// the reaper has not cleaned up yet.
+func (c *Expirable[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.evictList.Length()
+}
+
+// Cap returns the size of the cache.
+func (c *Expirable[K, V]) Cap() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.size
+}
+
+// Purge is used to completely clear the cache.
+func (c *Expirable[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, ent := range c.items {
+		c.removeElement(ent, cache.EvictReasonPurged)
+	}
+	c.evictList.Init()
+}
+
+// Resize changes the cache size.
+func (c *Expirable[K, V]) Resize(size int) (evicted int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if size <= 0 {
+		return c.evictList.Length() - size, errors.New("must provide a positive size")
+	}
+	diff := c.evictList.Length() - size
+	if diff < 0 {
+		diff = 0
+	}
+	for i := 0; i < diff; i++ {
+		c.removeOldest()
+	}
+	c.size = size
+	return diff, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *Expirable[K, V]) EnableStats() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Expirable[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// removeOldest removes the oldest item from the cache.
+func (c *Expirable[K, V]) removeOldest() {
+	if ent := c.evictList.Back(); ent != nil {
+		c.removeElement(ent, cache.EvictReasonCapacity)
+	}
+}
+
+// removeElement is used to remove a given list element from the cache
+func (c *Expirable[K, V]) removeElement(e *internal.Entry[K, V], reason cache.EvictReason) {
+	c.evictList.Remove(e)
+	delete(c.items, e.Key)
+	c.removeFromBucket(e)
+	c.stats.Evicted(reason, 1)
+	if c.onEvict != nil {
+		c.onEvict(e.Key, e.Value, reason)
+	}
+}
+
+// Reap cleans the next expiry bucket, as every tick of the background
+// reaper does. Entries whose TTL is longer than one rotation of the buckets
+// are moved to the bucket that matches their expiry.
+func (c *Expirable[K, V]) Reap() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	idx := c.nextCleanupBucket
+	c.nextCleanupBucket = (c.nextCleanupBucket + 1) % numBuckets
+
+	now := c.now()
+	var later []*internal.Entry[K, V]
+	for _, ent := range c.buckets[idx].entries {
+		if expired(ent, now) {
+			c.removeElement(ent, cache.EvictReasonExpired)
+		} else {
+			later = append(later, ent)
+		}
+	}
+	for _, ent := range later {
+		c.removeFromBucket(ent)
+		c.addToBucket(ent)
+	}
+}
+
+// addToBucket adds entry to the bucket cleaned by the first reaper tick at
+// or after its expiry, capped at one rotation of the buckets.
+func (c *Expirable[K, V]) addToBucket(e *internal.Entry[K, V]) {
+	if e.ExpiresAt.IsZero() {
+		return
+	}
+	ticks := (e.ExpiresAt.Sub(c.now()) + c.interval - 1) / c.interval
+	if ticks < 1 {
+		ticks = 1
+	}
+	if ticks > numBuckets-1 {
+		ticks = numBuckets - 1
+	}
+	e.ExpireBucket = uint8((int(c.nextCleanupBucket) + int(ticks) - 1) % numBuckets)
+	c.buckets[e.ExpireBucket].entries[e.Key] = e
+}
+
+// removeFromBucket removes the entry from its corresponding bucket.
+func (c *Expirable[K, V]) removeFromBucket(e *internal.Entry[K, V]) {
+	if e.ExpiresAt.IsZero() {
+		return
+	}
+	delete(c.buckets[e.ExpireBucket].entries, e.Key)
+}
+
+// expired reports whether the entry has a TTL that has elapsed at now.
+func expired[K comparable, V any](e *internal.Entry[K, V], now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
@@ -1,111 +1,184 @@
 package lru
 
 import (
+	"container/heap"
 	"errors"
+	"fast-cache/cache"
+	"sort"
 	"sync"
 )
 
+var _ cache.Cache[int, int] = (*LRUK[int, int])(nil)
+var _ cache.StatsProvider = (*LRUK[int, int])(nil)
+var _ cache.CapacityProvider = (*LRUK[int, int])(nil)
+
+// LRUK is a thread-safe fixed size LRU-K cache. Every key keeps the times
+// of its last K references, and the cache evicts the key with the largest
+// backward K-distance, i.e. the oldest K-th most recent reference. Keys
+// referenced fewer than K times have an infinite distance and go first,
+// oldest last reference first. The history of evicted keys is retained in
+// a bounded table so a key coming back quickly keeps its references.
 type LRUK[K comparable, V any] struct {
-	size       int
-	recentSize int
-	k          uint8
-	recent     Cache[K, V]
-	cnt        map[K]uint8
-	frequent   Cache[K, V]
-	lock       sync.RWMutex
+	size  int
+	k     int
+	now   uint64 // logical clock, ticks on every reference
+	items map[K]*lrukEntry[K, V]
+	queue lrukQueue[K, V]
+
+	// history retains the reference times of evicted keys, nil when the
+	// retained history size is zero.
+	history *LRU[K, []uint64]
+
+	stats *cache.StatsCounter
+	lock  sync.RWMutex
+}
+
+// lrukEntry is a cached key with its reference history.
+type lrukEntry[K comparable, V any] struct {
+	key   K
+	value V
+	// hist holds the logical times of the last K references, most recent
+	// first, kth is the K-th one or zero while there are fewer than K.
+	hist  []uint64
+	kth   uint64
+	index int
 }
 
 func NewLruK[K comparable, V any](size int, k uint8) (*LRUK[K, V], error) {
-	return NewLruKParams[K, V](size, Default2QRecentRatio, k)
+	return NewLruKParams[K, V](size, k, size)
 }
-func NewLruKParams[K comparable, V any](size int, recentRatio float64, k uint8) (*LRUK[K, V], error) {
+
+// NewLruKParams creates an LRUK that retains the history of at most
+// historySize evicted keys, zero disables the retained history.
+func NewLruKParams[K comparable, V any](size int, k uint8, historySize int) (*LRUK[K, V], error) {
 	if size <= 0 || k <= 0 {
 		return nil, errors.New("invalid size or k")
 	}
-	if recentRatio < 0.0 || recentRatio > 1.0 {
-		return nil, errors.New("invalid recent ratio")
-	}
-	recent, err := NewLRU[K, V](size, nil)
-	if err != nil {
-		return nil, err
+	if historySize < 0 {
+		return nil, errors.New("invalid history size")
 	}
-	frequent, err := NewLRU[K, V](size, nil)
-	if err != nil {
-		return nil, err
-	}
-	// Determine the sub-sizes
-	recentSize := int(float64(size) * recentRatio)
 	c := &LRUK[K, V]{
-		size:       size,
-		recentSize: recentSize,
-		k:          k,
-		cnt:        make(map[K]uint8, size),
-		recent:     recent,
-		frequent:   frequent,
+		size:  size,
+		k:     int(k),
+		items: make(map[K]*lrukEntry[K, V], size),
+		queue: make(lrukQueue[K, V], 0, size),
 	}
-	return c, nil
-}
-func (c *LRUK[K, V]) AddFreq(key K, value V) {
-	if c.cnt[key] >= c.k {
-		c.recent.Remove(key)
-		c.frequent.Add(key, value)
-		delete(c.cnt, key)
-	} else {
-		c.recent.MoveToFront(key)
+	if historySize > 0 {
+		history, err := NewLRU[K, []uint64](historySize, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.history = history
 	}
+	return c, nil
 }
+
+// Get looks up a key's value from the cache, recording a reference.
 func (c *LRUK[K, V]) Get(key K) (value V, ok bool) {
-	c.lock.RLock()
-	defer c.lock.RUnlock()
-	if value, ok = c.frequent.Get(key); ok {
-		return value, ok
-	}
-	if value, ok = c.recent.Peek(key); ok {
-		c.cnt[key]++
-		c.AddFreq(key, value)
-		return value, ok
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.reference(e)
+		c.stats.Hit()
+		return e.value, true
 	}
+	c.stats.Miss()
 	return
 }
 
-func (c *LRUK[K, V]) Add(key K, value V) {
+// Add adds a value to the cache, recording a reference. Returns true if an
+// eviction occurred.
+func (c *LRUK[K, V]) Add(key K, value V) (evicted bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	if _, ok := c.frequent.Get(key); ok {
-		c.frequent.Add(key, value)
-		return
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		c.reference(e)
+		c.stats.Updated()
+		return false
+	}
+
+	if len(c.items) >= c.size {
+		c.evict(cache.EvictReasonCapacity)
+		evicted = true
+	}
+
+	e := &lrukEntry[K, V]{key: key, value: value}
+	if c.history != nil {
+		if hist, ok := c.history.Peek(key); ok {
+			c.history.Remove(key)
+			e.hist = hist
+			if len(hist) == c.k {
+				// promoted before its eviction, not again
+				e.kth = hist[c.k-1]
+			}
+			c.stats.GhostHit()
+		}
+	}
+	c.items[key] = e
+	c.now++
+	if e.reference(c.now, c.k) {
+		c.stats.Promoted()
+	}
+	heap.Push(&c.queue, e)
+	c.stats.Added()
+	return evicted
+}
+
+// AddFreq adds a value to the cache, recording a reference.
+//
+// Deprecated: use Add.
+func (c *LRUK[K, V]) AddFreq(key K, value V) {
+	c.Add(key, value)
+}
+
+// reference records a reference to e at a new logical time.
+func (c *LRUK[K, V]) reference(e *lrukEntry[K, V]) {
+	c.now++
+	if e.reference(c.now, c.k) {
+		c.stats.Promoted()
+	}
+	heap.Fix(&c.queue, e.index)
+}
+
+// reference adds t as the most recent reference of e, keeping the last k.
+// Returns true when e reaches k references.
+func (e *lrukEntry[K, V]) reference(t uint64, k int) (promoted bool) {
+	if len(e.hist) < k {
+		e.hist = append(e.hist, 0)
+	}
+	copy(e.hist[1:], e.hist[:len(e.hist)-1])
+	e.hist[0] = t
+	if len(e.hist) == k {
+		promoted = e.kth == 0
+		e.kth = e.hist[k-1]
 	}
-	if c.recent.Contains(key) {
-		c.recent.MoveToFront(key)
-	} else {
-		c.recent.Add(key, value)
+	return promoted
+}
+
+// evict removes the entry with the largest backward K-distance, retaining
+// its history.
+func (c *LRUK[K, V]) evict(reason cache.EvictReason) {
+	e := heap.Pop(&c.queue).(*lrukEntry[K, V])
+	delete(c.items, e.key)
+	if c.history != nil {
+		c.history.Add(e.key, e.hist)
 	}
-	c.cnt[key]++
-	c.AddFreq(key, value)
+	c.stats.Evicted(reason, 1)
 }
 
 // Len returns the number of items in the cache.
 func (c *LRUK[K, V]) Len() int {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	return c.recent.Len() + c.frequent.Len()
+	return len(c.items)
 }
-func (c *LRUK[K, V]) ensureSpace() {
-	// If we have space, nothing to do
-	recentLen := c.recent.Len()
-	freqLen := c.frequent.Len()
-	if recentLen+freqLen < c.size {
-		return
-	}
 
-	// If the recent buffer is larger than
-	// the target, evict from there
-	if recentLen > 0 && (recentLen > c.recentSize || (recentLen == c.recentSize)) {
-		_, _, _ = c.recent.RemoveOldest()
-		return
-	}
-	// Remove from the frequent list otherwise
-	c.frequent.RemoveOldest()
+// Cap returns the size of the cache.
+func (c *LRUK[K, V]) Cap() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.size
 }
 
 // Resize changes the cache size.
@@ -113,80 +186,155 @@ func (c *LRUK[K, V]) Resize(size int) (evicted int, err error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	if size <= 0 {
-		return c.recent.Len() + c.frequent.Len() - size, errors.New("must provide a positive size")
-	}
-	// Recalculate the sub-sizes
-	c.size = size
-	// ensureSpace
-	diff := c.recent.Len() + c.frequent.Len() - size
-	if diff < 0 {
-		diff = 0
+		return len(c.items) - size, errors.New("must provide a positive size")
 	}
-	for i := 0; i < diff; i++ {
-		c.ensureSpace()
+	for len(c.items) > size {
+		c.evict(cache.EvictReasonCapacity)
+		evicted++
 	}
+	c.size = size
+	return evicted, nil
+}
 
-	// Reallocate the LRUs
-	_, _ = c.recent.Resize(size)
-	_, _ = c.frequent.Resize(size)
-	return diff, nil
+// sorted returns the entries from the next to be evicted to the last.
+func (c *LRUK[K, V]) sorted(reverse bool) []*lrukEntry[K, V] {
+	entries := make(lrukQueue[K, V], len(c.queue))
+	copy(entries, c.queue)
+	sort.Slice(entries, func(i, j int) bool {
+		if reverse {
+			return entries.Less(j, i)
+		}
+		return entries.Less(i, j)
+	})
+	return entries
 }
 
-// Keys returns a slice of the keys in the cache.
-// The frequently used keys are first in the returned slice.
+// Keys returns a slice of the keys in the cache, from the next to be
+// evicted to the last.
 func (c *LRUK[K, V]) Keys(reverse bool) []K {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	k1 := c.frequent.Keys(reverse)
-	k2 := c.recent.Keys(reverse)
-	return append(k1, k2...)
+	entries := c.sorted(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.key
+	}
+	return keys
 }
 
-// Values returns a slice of the values in the cache.
-// The frequently used values are first in the returned slice.
+// Values returns a slice of the values in the cache, in the same order as
+// Keys.
 func (c *LRUK[K, V]) Values(reverse bool) []V {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	v1 := c.frequent.Values(reverse)
-	v2 := c.recent.Values(reverse)
-	return append(v1, v2...)
+	entries := c.sorted(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.value
+	}
+	return values
 }
 
-// Remove removes the provided key from the cache.
-func (c *LRUK[K, V]) Remove(key K) {
+// Remove removes the provided key from the cache, returning if the
+// key was contained. The history of the key is forgotten.
+func (c *LRUK[K, V]) Remove(key K) (present bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	if c.frequent.Remove(key) {
-		return
+	if e, ok := c.items[key]; ok {
+		heap.Remove(&c.queue, e.index)
+		delete(c.items, key)
+		c.stats.Evicted(cache.EvictReasonRemoved, 1)
+		return true
 	}
-	if c.recent.Remove(key) {
-		return
+	if c.history != nil {
+		c.history.Remove(key)
 	}
+	return false
 }
 
-// Purge is used to completely clear the cache.
+// Purge is used to completely clear the cache and its history.
 func (c *LRUK[K, V]) Purge() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	c.recent.Purge()
-	c.frequent.Purge()
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	c.items = make(map[K]*lrukEntry[K, V], c.size)
+	c.queue = make(lrukQueue[K, V], 0, c.size)
+	if c.history != nil {
+		c.history.Purge()
+	}
 }
 
 // Contains is used to check if the cache contains a key
-// without updating recency or frequency.
+// without recording a reference.
 func (c *LRUK[K, V]) Contains(key K) bool {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	return c.frequent.Contains(key) || c.recent.Contains(key)
+	_, ok := c.items[key]
+	return ok
 }
 
 // Peek is used to inspect the cache value of a key
-// without updating recency or frequency.
+// without recording a reference.
 func (c *LRUK[K, V]) Peek(key K) (value V, ok bool) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	if val, ok := c.frequent.Peek(key); ok {
-		return val, ok
+	if e, ok := c.items[key]; ok {
+		return e.value, true
 	}
-	return c.recent.Peek(key)
+	return
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *LRUK[K, V]) EnableStats() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *LRUK[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// lrukQueue is a heap of entries ordered by eviction priority, the root is
+// the entry with the largest backward K-distance.
+type lrukQueue[K comparable, V any] []*lrukEntry[K, V]
+
+var _ heap.Interface = (*lrukQueue[struct{}, struct{}])(nil)
+
+func (q lrukQueue[K, V]) Len() int { return len(q) }
+
+func (q lrukQueue[K, V]) Less(i, j int) bool {
+	// a zero K-th reference time is an infinite distance, ties are broken
+	// by the last reference
+	a, b := q[i], q[j]
+	if a.kth != b.kth {
+		return a.kth < b.kth
+	}
+	return a.hist[0] < b.hist[0]
+}
+
+func (q lrukQueue[K, V]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *lrukQueue[K, V]) Push(x interface{}) {
+	e := x.(*lrukEntry[K, V])
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *lrukQueue[K, V]) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil // avoid memory leak
+	e.index = -1
+	*q = old[:n-1]
+	return e
 }
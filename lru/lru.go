@@ -2,18 +2,34 @@ package lru
 
 import (
 	"errors"
+	"fast-cache/cache"
 	"fast-cache/internal"
+	"io"
 )
 
 // EvictCallback is used to get a callback when a cache entry is evicted
 type EvictCallback[K comparable, V any] func(key K, value V)
 
+var _ cache.Cache[int, int] = (*LRU[int, int])(nil)
+var _ cache.StatsProvider = (*LRU[int, int])(nil)
+var _ cache.CapacityProvider = (*LRU[int, int])(nil)
+var _ cache.Snapshotter = (*LRU[int, int])(nil)
+
 // LRU implements a non-thread safe fixed size LRU cache
 type LRU[K comparable, V any] struct {
 	size      int
 	evictList *internal.LruList[K, V]
 	items     map[K]*internal.Entry[K, V]
 	onEvict   EvictCallback[K, V]
+	stats     *cache.StatsCounter
+	admit     cache.Admitter[K]
+	codec     cache.Codec[K, V]
+
+	// coster is set in cost mode, the cache then holds at most maxCost and
+	// cost is the total of the entries.
+	coster  cache.Coster[K, V]
+	cost    int64
+	maxCost int64
 }
 
 func New[K comparable, V any](size int) (*LRU[K, V], error) {
@@ -35,8 +51,29 @@ func NewLRU[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*LRU[K,
 	return c, nil
 }
 
+// NewLRUWithCost constructs an LRU bounded by the total cost of its entries
+// as returned by coster, rather than by their count.
+func NewLRUWithCost[K comparable, V any](maxCost int64, coster cache.Coster[K, V], onEvict EvictCallback[K, V]) (*LRU[K, V], error) {
+	if maxCost <= 0 {
+		return nil, errors.New("must provide a positive max cost")
+	}
+	if coster == nil {
+		return nil, errors.New("must provide a coster")
+	}
+
+	c := &LRU[K, V]{
+		evictList: internal.NewList[K, V](),
+		items:     make(map[K]*internal.Entry[K, V]),
+		onEvict:   onEvict,
+		coster:    coster,
+		maxCost:   maxCost,
+	}
+	return c, nil
+}
+
 // Purge is used to completely clear the cache.
 func (c *LRU[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
 	for k, v := range c.items {
 		if c.onEvict != nil {
 			c.onEvict(k, v.Value)
@@ -44,27 +81,14 @@ func (c *LRU[K, V]) Purge() {
 		delete(c.items, k)
 	}
 	c.evictList.Init()
+	c.cost = 0
 }
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
+// In cost mode an entry costing more than the max cost is not cached, and
+// an older value of its key is removed.
 func (c *LRU[K, V]) Add(key K, value V) (evicted bool) {
-	// Check for existing item
-	if ent, ok := c.items[key]; ok {
-		c.evictList.MoveToFront(ent)
-		ent.Value = value
-		return false
-	}
-
-	// Add new item
-	ent := c.evictList.PushFront(key, value)
-	c.items[key] = ent
-
-	evict := c.evictList.Length() > c.size
-	// Verify size not exceeded
-	if evict {
-		c.removeOldest()
-	}
-	return evict
+	return c.add(key, value) > 0
 }
 
 // AddMany adds multiple values to the cache. Returns the number of evicted items.
@@ -75,34 +99,77 @@ func (c *LRU[K, V]) AddMany(keys []K, values []V) (evicted int) {
 	}
 
 	for i := 0; i < len(keys); i++ {
-		key := keys[i]
-		value := values[i]
+		evicted += c.add(keys[i], values[i])
+	}
+	return evicted
+}
 
-		if ent, ok := c.items[key]; ok {
-			c.evictList.MoveToFront(ent)
-			ent.Value = value
-			continue
+// add adds a value to the cache, returning the number of evicted items.
+func (c *LRU[K, V]) add(key K, value V) (evicted int) {
+	c.admit.Record(key)
+	var cost int64
+	if c.coster != nil {
+		if cost = c.coster(key, value); cost < 0 || cost > c.maxCost {
+			if ent, ok := c.items[key]; ok {
+				c.removeElement(ent, cache.EvictReasonRemoved)
+			}
+			return 0
 		}
+	}
 
-		// add new item
+	// Check for existing item
+	if ent, ok := c.items[key]; ok {
+		c.evictList.MoveToFront(ent)
+		ent.Value = value
+		c.cost += cost - ent.Cost
+		ent.Cost = cost
+		c.stats.Updated()
+	} else {
+		if c.admit.Enabled() && c.full(cost) && !c.admit.Admit(key, c.evictList.Back().Key) {
+			return 0
+		}
+		// Add new item
 		ent := c.evictList.PushFront(key, value)
+		ent.Cost = cost
 		c.items[key] = ent
-
-		if c.evictList.Length() > c.size {
-			c.removeOldest()
-			evicted++
-		}
+		c.cost += cost
+		c.stats.Added()
 	}
 
+	// Verify size not exceeded
+	for c.overCapacity() {
+		c.removeOldest()
+		evicted++
+	}
 	return evicted
 }
 
+// full returns if adding an entry of the given cost requires an eviction.
+func (c *LRU[K, V]) full(cost int64) bool {
+	if c.coster != nil {
+		return c.cost+cost > c.maxCost
+	}
+	return c.evictList.Length() >= c.size
+}
+
+// overCapacity returns if the cache holds more than its size, or its max
+// cost in cost mode.
+func (c *LRU[K, V]) overCapacity() bool {
+	if c.coster != nil {
+		return c.cost > c.maxCost
+	}
+	return c.evictList.Length() > c.size
+}
+
 // Get looks up a key's value from the cache.
 func (c *LRU[K, V]) Get(key K) (value V, ok bool) {
+	c.admit.Record(key)
 	if ent, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ent)
+		c.stats.Hit()
 		return ent.Value, true
 	}
+	c.stats.Miss()
 	return
 }
 
@@ -127,7 +194,7 @@ func (c *LRU[K, V]) Peek(key K) (value V, ok bool) {
 // key was contained.
 func (c *LRU[K, V]) Remove(key K) (present bool) {
 	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
+		c.removeElement(ent, cache.EvictReasonRemoved)
 		return true
 	}
 	return false
@@ -136,7 +203,7 @@ func (c *LRU[K, V]) Remove(key K) (present bool) {
 func (c *LRU[K, V]) RemoveMany(keys []K) (removed int) {
 	for _, key := range keys {
 		if ent, ok := c.items[key]; ok {
-			c.removeElement(ent)
+			c.removeElement(ent, cache.EvictReasonRemoved)
 			removed++
 		}
 	}
@@ -146,7 +213,7 @@ func (c *LRU[K, V]) RemoveMany(keys []K) (removed int) {
 // RemoveOldest removes the oldest item from the cache.
 func (c *LRU[K, V]) RemoveOldest() (key K, value V, ok bool) {
 	if ent := c.evictList.Back(); ent != nil {
-		c.removeElement(ent)
+		c.removeElement(ent, cache.EvictReasonRemoved)
 		return ent.Key, ent.Value, true
 	}
 	return
@@ -201,38 +268,55 @@ func (c *LRU[K, V]) Len() int {
 	return c.evictList.Length()
 }
 
-// Resize changes the cache size.
+// Cap returns the size of the cache, its max cost in cost mode.
+func (c *LRU[K, V]) Cap() int {
+	if c.coster != nil {
+		return int(c.maxCost)
+	}
+	return c.size
+}
+
+// Resize changes the cache size, in cost units in cost mode.
 func (c *LRU[K, V]) Resize(size int) (evicted int, err error) {
 	if size <= 0 {
 		return c.Len() - size, errors.New("must provide a positive size")
 	}
-	diff := c.Len() - size
-	if diff < 0 {
-		diff = 0
+	if c.coster != nil {
+		c.maxCost = int64(size)
+	} else {
+		c.size = size
 	}
-	for i := 0; i < diff; i++ {
+	for c.overCapacity() {
 		c.removeOldest()
+		evicted++
 	}
-	c.size = size
-	return diff, nil
+	return evicted, nil
+}
+
+// Cost returns the total cost of the entries in cost mode, zero otherwise.
+func (c *LRU[K, V]) Cost() int64 {
+	return c.cost
 }
 
 // removeOldest removes the oldest item from the cache.
 func (c *LRU[K, V]) removeOldest() {
 	if ent := c.evictList.Back(); ent != nil {
-		c.removeElement(ent)
+		c.removeElement(ent, cache.EvictReasonCapacity)
 	}
 }
 
 // removeElement is used to remove a given list element from the cache
-func (c *LRU[K, V]) removeElement(e *internal.Entry[K, V]) {
+func (c *LRU[K, V]) removeElement(e *internal.Entry[K, V], reason cache.EvictReason) {
 	c.evictList.Remove(e)
 	delete(c.items, e.Key)
+	c.cost -= e.Cost
+	c.stats.Evicted(reason, 1)
 	if c.onEvict != nil {
 		c.onEvict(e.Key, e.Value)
 	}
 }
 
+// MoveToFront marks the key as the most recently used without counting a hit.
 func (c *LRU[K, V]) MoveToFront(key K) (ok bool) {
 	if ent, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ent)
@@ -240,3 +324,69 @@ func (c *LRU[K, V]) MoveToFront(key K) (ok bool) {
 	}
 	return false
 }
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *LRU[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *LRU[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
+
+// SetAdmission installs the admission policy consulted before a new key
+// evicts an entry, nil admits every key.
+func (c *LRU[K, V]) SetAdmission(admission cache.Admission[K]) {
+	c.admit.Set(admission)
+}
+
+// SetCodec sets the codec of Snapshot and Restore, nil is cache.GobCodec.
+func (c *LRU[K, V]) SetCodec(codec cache.Codec[K, V]) {
+	c.codec = codec
+}
+
+// Snapshot writes the entries of the cache to w, from oldest to newest.
+func (c *LRU[K, V]) Snapshot(w io.Writer) error {
+	s := &cache.Snapshot[K, V]{
+		Policy:  "lru",
+		Entries: make([]cache.SnapshotEntry[K, V], 0, len(c.items)),
+	}
+	for ent := c.evictList.Back(); ent != nil; ent = ent.PrevEntry() {
+		s.Entries = append(s.Entries, cache.SnapshotEntry[K, V]{Key: ent.Key, Value: ent.Value})
+	}
+	return cache.WriteSnapshot(w, c.codec, s)
+}
+
+// Restore purges the cache and loads a snapshot written by Snapshot, with
+// the same recency order. Entries that do not fit are evicted, oldest
+// first.
+func (c *LRU[K, V]) Restore(r io.Reader) error {
+	s, err := cache.ReadSnapshot(r, "lru", c.codec)
+	if err != nil {
+		return err
+	}
+	c.Purge()
+	for _, e := range s.Entries {
+		var cost int64
+		if c.coster != nil {
+			if cost = c.coster(e.Key, e.Value); cost < 0 || cost > c.maxCost {
+				continue
+			}
+		}
+		if ent, ok := c.items[e.Key]; ok {
+			c.removeElement(ent, cache.EvictReasonRemoved)
+		}
+		ent := c.evictList.PushFront(e.Key, e.Value)
+		ent.Cost = cost
+		c.items[e.Key] = ent
+		c.cost += cost
+	}
+	for c.overCapacity() {
+		c.removeOldest()
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package lru
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+	"sync"
+)
+
+// DefaultSLRUProtectedRatio is the ratio of the SLRU cache dedicated to
+// the protected segment.
+const DefaultSLRUProtectedRatio = 0.80
+
+var _ cache.Cache[int, int] = (*SLRU[int, int])(nil)
+var _ cache.StatsProvider = (*SLRU[int, int])(nil)
+var _ cache.CapacityProvider = (*SLRU[int, int])(nil)
+
+// SLRU is a thread-safe fixed size segmented LRU cache. New entries enter
+// the probationary segment and move to the protected segment when they are
+// referenced again. When the protected segment is full its LRU entry is
+// demoted back to the front of probation rather than evicted, so evictions
+// only ever come from the tail of probation.
+type SLRU[K comparable, V any] struct {
+	size           int
+	protectedSize  int
+	protectedRatio float64
+	items          map[K]*slruNode[K, V]
+	probation      internal.LruList[K, V]
+	protected      internal.LruList[K, V]
+	stats          *cache.StatsCounter
+	lock           sync.RWMutex
+}
+
+// slruNode is an entry with the segment it belongs to.
+type slruNode[K comparable, V any] struct {
+	internal.Entry[K, V]
+	protected bool
+}
+
+// NewSLRU creates an SLRU of the given size, protectedRatio is the share
+// of the cache given to the protected segment.
+func NewSLRU[K comparable, V any](size int, protectedRatio float64) (*SLRU[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("invalid size")
+	}
+	if protectedRatio < 0.0 || protectedRatio > 1.0 {
+		return nil, errors.New("invalid protected ratio")
+	}
+	c := &SLRU[K, V]{
+		size:           size,
+		protectedSize:  int(float64(size) * protectedRatio),
+		protectedRatio: protectedRatio,
+		items:          make(map[K]*slruNode[K, V], size),
+	}
+	return c, nil
+}
+
+// Get looks up a key's value from the cache.
+func (c *SLRU[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if n, ok := c.items[key]; ok {
+		c.touch(n)
+		c.stats.Hit()
+		return n.Value, true
+	}
+	c.stats.Miss()
+	return
+}
+
+// Add adds a value to the cache, returns true if an eviction occurred.
+func (c *SLRU[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// An existing entry is referenced again, so it is promoted
+	if n, ok := c.items[key]; ok {
+		n.Value = value
+		c.touch(n)
+		c.stats.Updated()
+		return false
+	}
+
+	if len(c.items) >= c.size {
+		c.removeOldest(cache.EvictReasonCapacity)
+		evicted = true
+	}
+	n := &slruNode[K, V]{}
+	n.Key, n.Value = key, value
+	c.probation.PushEntryFront(&n.Entry)
+	c.items[key] = n
+	c.stats.Added()
+	return evicted
+}
+
+// touch records a reference to n, promoting it out of probation.
+func (c *SLRU[K, V]) touch(n *slruNode[K, V]) {
+	if n.protected {
+		c.protected.MoveToFront(&n.Entry)
+		return
+	}
+	c.probation.Remove(&n.Entry)
+	n.protected = true
+	c.protected.PushEntryFront(&n.Entry)
+	c.stats.Promoted()
+	c.demote()
+}
+
+// demote moves the protected LRU entries to the front of probation while
+// the protected segment is over its size.
+func (c *SLRU[K, V]) demote() {
+	for c.protected.Length() > c.protectedSize {
+		n := c.items[c.protected.Back().Key]
+		c.protected.Remove(&n.Entry)
+		n.protected = false
+		c.probation.PushEntryFront(&n.Entry)
+	}
+}
+
+// removeOldest evicts the LRU entry of probation, or of the protected
+// segment if probation is empty.
+func (c *SLRU[K, V]) removeOldest(reason cache.EvictReason) {
+	e := c.probation.Back()
+	if e == nil {
+		e = c.protected.Back()
+	}
+	if e != nil {
+		c.removeNode(c.items[e.Key], reason)
+	}
+}
+
+// removeNode unlinks n from its segment and drops it from the cache.
+func (c *SLRU[K, V]) removeNode(n *slruNode[K, V], reason cache.EvictReason) {
+	if n.protected {
+		c.protected.Remove(&n.Entry)
+	} else {
+		c.probation.Remove(&n.Entry)
+	}
+	delete(c.items, n.Key)
+	c.stats.Evicted(reason, 1)
+}
+
+// Len returns the number of items in the cache.
+func (c *SLRU[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return len(c.items)
+}
+
+// Cap returns the size of the cache.
+func (c *SLRU[K, V]) Cap() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.size
+}
+
+// Resize changes the cache size.
+func (c *SLRU[K, V]) Resize(size int) (evicted int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if size <= 0 {
+		return len(c.items) - size, errors.New("must provide a positive size")
+	}
+	c.size = size
+	c.protectedSize = int(float64(size) * c.protectedRatio)
+	for len(c.items) > size {
+		c.removeOldest(cache.EvictReasonCapacity)
+		evicted++
+	}
+	c.demote()
+	return evicted, nil
+}
+
+// entries returns the entries from the next to be evicted to the last:
+// probation then protected, each from least recently used.
+func (c *SLRU[K, V]) entries(reverse bool) []*internal.Entry[K, V] {
+	entries := make([]*internal.Entry[K, V], 0, len(c.items))
+	for _, l := range []*internal.LruList[K, V]{&c.probation, &c.protected} {
+		for e := l.Back(); e != nil; e = e.PrevEntry() {
+			entries = append(entries, e)
+		}
+	}
+	if reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	return entries
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be
+// evicted to the last.
+func (c *SLRU[K, V]) Keys(reverse bool) []K {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	entries := c.entries(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, in the same order as
+// Keys.
+func (c *SLRU[K, V]) Values(reverse bool) []V {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	entries := c.entries(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Value
+	}
+	return values
+}
+
+// Remove removes the provided key from the cache.
+func (c *SLRU[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if n, ok := c.items[key]; ok {
+		c.removeNode(n, cache.EvictReasonRemoved)
+		return true
+	}
+	return false
+}
+
+// Purge is used to completely clear the cache.
+func (c *SLRU[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	c.items = make(map[K]*slruNode[K, V], c.size)
+	c.probation.Init()
+	c.protected.Init()
+}
+
+// Contains is used to check if the cache contains a key
+// without updating recency.
+func (c *SLRU[K, V]) Contains(key K) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	_, ok := c.items[key]
+	return ok
+}
+
+// Peek is used to inspect the cache value of a key
+// without updating recency.
+func (c *SLRU[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if n, ok := c.items[key]; ok {
+		return n.Value, true
+	}
+	return
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *SLRU[K, V]) EnableStats() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *SLRU[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
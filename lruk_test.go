@@ -35,3 +35,77 @@ func TestLRUK(t *testing.T) {
 
 	fmt.Println(l.Values(true))
 }
+
+func TestLRUK_KDistance(t *testing.T) {
+	l, err := lru.NewLruK[int, int](3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.Add(1, 1)
+	l.Add(2, 2)
+	l.Add(3, 3)
+	l.Get(1)
+	l.Get(2)
+
+	// 3 was referenced once, its backward 2-distance is infinite
+	if !l.Add(4, 4) || l.Contains(3) {
+		t.Fatalf("3 should be evicted: %v", l.Keys(false))
+	}
+	l.Get(4)
+	l.Get(1)
+
+	// 2 has the oldest second to last reference
+	if !l.Add(5, 5) || l.Contains(2) {
+		t.Fatalf("2 should be evicted: %v", l.Keys(false))
+	}
+	if keys := fmt.Sprint(l.Keys(false)); keys != "[5 1 4]" {
+		t.Fatalf("Keys(false) = %s", keys)
+	}
+
+	// 2 comes back with its retained history and outlives 5
+	if !l.Add(2, 2) || l.Contains(5) || !l.Contains(2) {
+		t.Fatalf("5 should be evicted: %v", l.Keys(false))
+	}
+}
+
+func TestLRUK_Size(t *testing.T) {
+	l, err := lru.NewLruKParams[int, int](8, 2, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		l.Add(i%13, i)
+		l.Get(i % 7)
+		if l.Len() > 8 {
+			t.Fatalf("Len() = %d, want <= 8", l.Len())
+		}
+	}
+	if n, err := l.Resize(4); err != nil || n != 4 || l.Len() != 4 {
+		t.Fatalf("Resize(4) = %d, %v, Len() = %d", n, err, l.Len())
+	}
+}
+
+func TestLRUK_Promotions(t *testing.T) {
+	l, err := lru.NewLruKParams[int, int](1, 2, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l.EnableStats()
+	l.Add(1, 1)
+	l.AddFreq(1, 1)
+	if s := l.Stats(); s.Promotions != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	// 1 comes back with its retained history, it was promoted already
+	l.Add(2, 2)
+	l.Add(1, 1)
+	if s := l.Stats(); s.GhostHits != 1 || s.Promotions != 1 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	// 2 reaches its second reference through its retained history
+	l.Add(2, 2)
+	if s := l.Stats(); s.GhostHits != 2 || s.Promotions != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
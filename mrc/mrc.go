@@ -0,0 +1,212 @@
+// Package mrc estimates the miss-ratio curve of an LRU cache, its miss
+// ratio at every size, in one pass over a trace. The stack distance of an
+// access, the number of distinct keys accessed since the last access of
+// its key, is the smallest LRU size that hits it, so a histogram of stack
+// distances gives the whole curve. Distances are counted with a Fenwick
+// tree over the times of the last access of every key, in O(log n) time per
+// access.
+//
+// For large traces the analyzer can sample keys as in SHARDS: only keys
+// whose hash falls below a threshold are tracked, and their distances are
+// scaled by the sampling rate. Memory and time then shrink by the rate at a
+// small loss of accuracy. As in SHARDS-adj, the hits of the smallest
+// distance absorb the difference between the sampled accesses and the
+// expected ones, so a popular key falling in or out of the sample does not
+// skew the whole curve.
+package mrc
+
+import (
+	"errors"
+	"fast-cache/internal"
+	"math"
+	"sort"
+)
+
+// sampleModulus is the range of the key hashes compared to the sampling
+// threshold.
+const sampleModulus = 1 << 24
+
+// minTimes is the smallest number of access times the Fenwick tree holds.
+const minTimes = 1024
+
+// Analyzer records the stack distances of the accesses of a trace. It is
+// not thread safe.
+type Analyzer[K comparable] struct {
+	rate      float64
+	threshold uint64 // keys whose hash modulo sampleModulus is below are sampled
+
+	last map[K]int // time of the last access of every key
+	tree []int     // Fenwick tree marking the times in last
+	now  int       // time of the next access
+
+	hist     []uint64 // hist[d-1] counts the accesses of stack distance d
+	cold     uint64   // first accesses of a key
+	accesses uint64
+}
+
+// New constructs an Analyzer tracking every key.
+func New[K comparable]() *Analyzer[K] {
+	a, _ := NewSampled[K](1)
+	return a
+}
+
+// NewSampled constructs an Analyzer tracking the given fraction of the
+// keys, chosen by their hash, the SHARDS sampling rate.
+func NewSampled[K comparable](rate float64) (*Analyzer[K], error) {
+	if !(rate > 0 && rate <= 1) {
+		return nil, errors.New("invalid sample rate")
+	}
+	a := &Analyzer[K]{
+		rate:      rate,
+		threshold: uint64(math.Ceil(rate * sampleModulus)),
+		last:      make(map[K]int),
+		tree:      make([]int, minTimes+1),
+	}
+	return a, nil
+}
+
+// Access records an access of key.
+func (a *Analyzer[K]) Access(key K) {
+	a.accesses++
+	if a.rate < 1 && internal.Hash(key)%sampleModulus >= a.threshold {
+		return
+	}
+	if a.now == len(a.tree)-1 {
+		a.compact()
+	}
+	if t, ok := a.last[key]; ok {
+		// the keys accessed since t, and key itself
+		d := a.count(a.now-1) - a.count(t) + 1
+		for len(a.hist) < d {
+			a.hist = append(a.hist, 0)
+		}
+		a.hist[d-1]++
+		a.mark(t, -1)
+	} else {
+		a.cold++
+	}
+	a.mark(a.now, 1)
+	a.last[key] = a.now
+	a.now++
+}
+
+// mark adds delta to the mark of time t.
+func (a *Analyzer[K]) mark(t, delta int) {
+	for i := t + 1; i < len(a.tree); i += i & -i {
+		a.tree[i] += delta
+	}
+}
+
+// count returns the number of marked times up to t included.
+func (a *Analyzer[K]) count(t int) (n int) {
+	for i := t + 1; i > 0; i -= i & -i {
+		n += a.tree[i]
+	}
+	return n
+}
+
+// compact renumbers the last access times from zero, keeping their order,
+// so the tree only needs room for twice the number of keys.
+func (a *Analyzer[K]) compact() {
+	keys := make([]K, 0, len(a.last))
+	for k := range a.last {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return a.last[keys[i]] < a.last[keys[j]]
+	})
+	a.tree = make([]int, max(minTimes, 2*len(keys))+1)
+	for t, k := range keys {
+		a.last[k] = t
+		a.mark(t, 1)
+	}
+	a.now = len(keys)
+}
+
+// Curve returns the miss-ratio curve of the accesses recorded so far.
+func (a *Analyzer[K]) Curve() *Curve {
+	c := &Curve{
+		Accesses: a.accesses,
+		Sampled:  a.cold,
+		rate:     a.rate,
+		hits:     make([]uint64, len(a.hist)),
+	}
+	var hits uint64
+	for i, n := range a.hist {
+		hits += n
+		c.hits[i] = hits
+	}
+	c.Sampled += hits
+	c.expected = float64(a.accesses) * a.rate
+	c.adjust = c.expected - float64(c.Sampled)
+	return c
+}
+
+// Curve is the miss-ratio curve of an LRU cache over a trace.
+type Curve struct {
+	// Accesses is the number of accesses of the trace.
+	Accesses uint64
+	// Sampled is the number of accesses of the sampled keys, all of them
+	// unless sampling.
+	Sampled uint64
+
+	rate     float64
+	hits     []uint64 // hits[d-1] counts the accesses of stack distance up to d
+	expected float64  // accesses of the sampled keys expected from the rate
+	adjust   float64  // hits added at the smallest distance
+}
+
+// MissRatio returns the miss ratio of an LRU cache of the given size, zero
+// if no access was sampled.
+func (c *Curve) MissRatio(size int) float64 {
+	if c.Sampled == 0 {
+		return 0
+	}
+	d := c.distance(size)
+	if d == 0 {
+		return 1
+	}
+	return min(1, max(0, 1-(float64(c.hits[d-1])+c.adjust)/c.expected))
+}
+
+// distance returns the largest sampled stack distance an LRU cache of the
+// given size hits, zero for none.
+func (c *Curve) distance(size int) int {
+	// a sampled distance is the true one scaled by the rate
+	return max(0, min(int(float64(size)*c.rate), len(c.hits)))
+}
+
+// sizeOf returns the smallest size hitting the sampled accesses of stack
+// distance up to d, the inverse of distance.
+func (c *Curve) sizeOf(d int) int {
+	size := max(1, int(math.Ceil(float64(d)/c.rate)))
+	// correct the rounding of the division
+	for size > 1 && int(float64(size-1)*c.rate) >= d {
+		size--
+	}
+	for int(float64(size)*c.rate) < d {
+		size++
+	}
+	return size
+}
+
+// MaxSize returns the size past which the miss ratio no longer falls, as
+// only first accesses miss.
+func (c *Curve) MaxSize() int {
+	return c.sizeOf(len(c.hits))
+}
+
+// SizeFor returns the smallest positive LRU size whose miss ratio is at
+// most missRatio, false if even MaxSize misses more often.
+func (c *Curve) SizeFor(missRatio float64) (size int, ok bool) {
+	if c.Sampled == 0 || missRatio >= 1 {
+		return 1, true
+	}
+	d := sort.Search(len(c.hits), func(i int) bool {
+		return c.MissRatio(c.sizeOf(i+1)) <= missRatio
+	})
+	if d == len(c.hits) {
+		return 0, false
+	}
+	return c.sizeOf(d + 1), true
+}
@@ -0,0 +1,119 @@
+package mrc
+
+import (
+	"fast-cache/lru"
+	"fast-cache/workload"
+	"math"
+	"testing"
+)
+
+// lruMisses replays keys through an LRU of the given size and returns its
+// miss ratio.
+func lruMisses(t *testing.T, keys []uint64, size int) float64 {
+	t.Helper()
+	c, err := lru.New[uint64, struct{}](size)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	hits := workload.Replay[struct{}](c, keys)
+	return 1 - float64(hits)/float64(len(keys))
+}
+
+func TestCurve_Exact(t *testing.T) {
+	// long enough for the access times to be compacted many times
+	g, _ := workload.NewZipf(1, 0.9, 2000)
+	keys := workload.Keys(g, 50000)
+	a := New[uint64]()
+	for _, k := range keys {
+		a.Access(k)
+	}
+	curve := a.Curve()
+	if curve.Accesses != 50000 || curve.Sampled != 50000 {
+		t.Fatalf("bad: %d %d", curve.Accesses, curve.Sampled)
+	}
+	for _, size := range []int{1, 10, 100, 500, 1000, 2000, 4000} {
+		if got, want := curve.MissRatio(size), lruMisses(t, keys, size); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("size %d: miss ratio %f, want %f", size, got, want)
+		}
+	}
+	if got := curve.MissRatio(0); got != 1 {
+		t.Fatalf("bad: %f", got)
+	}
+}
+
+func TestCurve_Sampled(t *testing.T) {
+	g, _ := workload.NewZipf(1, 0.8, 100000)
+	keys := workload.Keys(g, 500000)
+	a, err := NewSampled[uint64](0.1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, k := range keys {
+		a.Access(k)
+	}
+	curve := a.Curve()
+	if curve.Sampled > curve.Accesses/5 {
+		t.Fatalf("bad: sampled %d of %d", curve.Sampled, curve.Accesses)
+	}
+	for _, size := range []int{1000, 10000, 50000} {
+		if got, want := curve.MissRatio(size), lruMisses(t, keys, size); math.Abs(got-want) > 0.02 {
+			t.Fatalf("size %d: miss ratio %f, want %f", size, got, want)
+		}
+	}
+}
+
+func TestCurve_SizeFor(t *testing.T) {
+	// a loop over 100 keys only hits an LRU holding all of them
+	g, _ := workload.NewLoop(100)
+	a := New[uint64]()
+	for _, k := range workload.Keys(g, 1000) {
+		a.Access(k)
+	}
+	curve := a.Curve()
+	if got := curve.MissRatio(99); got != 1 {
+		t.Fatalf("bad: %f", got)
+	}
+	if got := curve.MissRatio(100); math.Abs(got-0.1) > 1e-9 {
+		t.Fatalf("bad: %f", got)
+	}
+	if got := curve.MaxSize(); got != 100 {
+		t.Fatalf("bad: %d", got)
+	}
+	if size, ok := curve.SizeFor(0.5); !ok || size != 100 {
+		t.Fatalf("bad: %d %v", size, ok)
+	}
+	if size, ok := curve.SizeFor(1); !ok || size != 1 {
+		t.Fatalf("bad: %d %v", size, ok)
+	}
+	// first accesses always miss
+	if _, ok := curve.SizeFor(0.05); ok {
+		t.Fatalf("should not reach a miss ratio below the cold misses")
+	}
+}
+
+func TestCurve_SampledSize(t *testing.T) {
+	a, err := NewSampled[uint64](0.3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	g, _ := workload.NewLoop(1000)
+	for _, k := range workload.Keys(g, 10000) {
+		a.Access(k)
+	}
+	curve := a.Curve()
+	size, ok := curve.SizeFor(0.5)
+	if !ok || curve.MissRatio(size) > 0.5 || curve.MissRatio(size-1) <= 0.5 {
+		t.Fatalf("bad: %d %v", size, ok)
+	}
+	if size < 900 || size > 1100 {
+		t.Fatalf("bad: %d", size)
+	}
+}
+
+func TestNewSampled_Invalid(t *testing.T) {
+	for _, rate := range []float64{0, -1, 1.5, math.NaN()} {
+		if _, err := NewSampled[int](rate); err == nil {
+			t.Fatalf("rate %v should fail", rate)
+		}
+	}
+}
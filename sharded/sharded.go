@@ -0,0 +1,205 @@
+package sharded
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+	"fast-cache/synced"
+)
+
+var _ cache.Cache[int, int] = (*Sharded[int, int])(nil)
+var _ cache.StatsProvider = (*Sharded[int, int])(nil)
+var _ cache.CapacityProvider = (*Sharded[int, int])(nil)
+
+// Hasher maps a key to the hash used to pick its shard.
+type Hasher[K comparable] func(key K) uint64
+
+// NewShard constructs one shard of the given size, any policy works.
+type NewShard[K comparable, V any] func(size int) (cache.Cache[K, V], error)
+
+// DefaultHasher hashes any comparable key, common key types are hashed
+// directly and everything else with hash/maphash.
+func DefaultHasher[K comparable](key K) uint64 {
+	return internal.Hash(key)
+}
+
+// Sharded is a thread-safe cache that spreads keys across independently
+// locked shards (each a synced.Cache), so goroutines touching different
+// shards never contend. Capacity is split evenly between the shards and
+// every shard evicts on its own, so the policy is applied per shard rather
+// than globally.
+type Sharded[K comparable, V any] struct {
+	shards []*synced.Cache[K, V]
+	hasher Hasher[K]
+}
+
+// New creates a Sharded cache of the given total size using DefaultHasher.
+func New[K comparable, V any](size, shards int, newShard NewShard[K, V]) (*Sharded[K, V], error) {
+	return NewParams[K, V](size, shards, DefaultHasher[K], newShard)
+}
+
+// NewParams creates a Sharded cache of the given total size using the
+// provided hasher.
+func NewParams[K comparable, V any](size, shards int, hasher Hasher[K], newShard NewShard[K, V]) (*Sharded[K, V], error) {
+	if shards <= 0 {
+		return nil, errors.New("must provide a positive number of shards")
+	}
+	if size < shards {
+		return nil, errors.New("size must be at least the number of shards")
+	}
+	if hasher == nil || newShard == nil {
+		return nil, errors.New("must provide a hasher and a shard constructor")
+	}
+
+	c := &Sharded[K, V]{
+		shards: make([]*synced.Cache[K, V], shards),
+		hasher: hasher,
+	}
+	for i := range c.shards {
+		sc, err := newShard(shardSize(size, shards, i))
+		if err != nil {
+			return nil, err
+		}
+		c.shards[i] = synced.Wrap[K, V](sc)
+	}
+	return c, nil
+}
+
+// shardSize returns the capacity of shard i, the remainder of the split
+// goes to the first shards.
+func shardSize(size, shards, i int) int {
+	n := size / shards
+	if i < size%shards {
+		n++
+	}
+	return n
+}
+
+func (c *Sharded[K, V]) shardFor(key K) *synced.Cache[K, V] {
+	return c.shards[c.hasher(key)%uint64(len(c.shards))]
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *Sharded[K, V]) Add(key K, value V) (evicted bool) {
+	return c.shardFor(key).Add(key, value)
+}
+
+// Get looks up a key's value from the cache.
+func (c *Sharded[K, V]) Get(key K) (value V, ok bool) {
+	return c.shardFor(key).Get(key)
+}
+
+// Contains checks if a key is in the cache, without updating the policy
+// bookkeeping of the key.
+func (c *Sharded[K, V]) Contains(key K) (ok bool) {
+	return c.shardFor(key).Contains(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the policy bookkeeping of the key.
+func (c *Sharded[K, V]) Peek(key K) (value V, ok bool) {
+	return c.shardFor(key).Peek(key)
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// policy bookkeeping, and if not, adds the value atomically within its shard.
+// Returns whether found and whether an eviction occurred.
+func (c *Sharded[K, V]) ContainsOrAdd(key K, value V) (ok, evicted bool) {
+	return c.shardFor(key).ContainsOrAdd(key, value)
+}
+
+// PeekOrAdd checks if a key is in the cache without updating the policy
+// bookkeeping, and if not, adds the value atomically within its shard.
+// Returns the previous value, whether found and whether an eviction occurred.
+func (c *Sharded[K, V]) PeekOrAdd(key K, value V) (previous V, ok, evicted bool) {
+	return c.shardFor(key).PeekOrAdd(key, value)
+}
+
+// GetOrAdd looks up a key's value, and if not found, adds the value
+// atomically within its shard.
+// Returns the previous value, whether found and whether an eviction occurred.
+func (c *Sharded[K, V]) GetOrAdd(key K, value V) (previous V, ok, evicted bool) {
+	return c.shardFor(key).GetOrAdd(key, value)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Sharded[K, V]) Remove(key K) (present bool) {
+	return c.shardFor(key).Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache, shard by shard, each
+// shard in its own policy order.
+func (c *Sharded[K, V]) Keys(reverse bool) []K {
+	var keys []K
+	for _, s := range c.shards {
+		keys = append(keys, s.Keys(reverse)...)
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, in the same order as Keys.
+func (c *Sharded[K, V]) Values(reverse bool) []V {
+	var values []V
+	for _, s := range c.shards {
+		values = append(values, s.Values(reverse)...)
+	}
+	return values
+}
+
+// Len returns the number of items in the cache.
+func (c *Sharded[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Len()
+	}
+	return n
+}
+
+// Cap returns the total capacity of the shards.
+func (c *Sharded[K, V]) Cap() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.Cap()
+	}
+	return n
+}
+
+// Purge is used to completely clear the cache.
+func (c *Sharded[K, V]) Purge() {
+	for _, s := range c.shards {
+		s.Purge()
+	}
+}
+
+// Resize changes the total cache size, splitting it across the shards
+// the same way as the constructor.
+func (c *Sharded[K, V]) Resize(size int) (evicted int, err error) {
+	if size < len(c.shards) {
+		return 0, errors.New("size must be at least the number of shards")
+	}
+	for i, s := range c.shards {
+		n, err := s.Resize(shardSize(size, len(c.shards), i))
+		if err != nil {
+			return evicted, err
+		}
+		evicted += n
+	}
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats in every shard, it must be called
+// before the cache is shared between goroutines.
+func (c *Sharded[K, V]) EnableStats() {
+	for _, s := range c.shards {
+		s.EnableStats()
+	}
+}
+
+// Stats returns the counters of all shards merged together.
+func (c *Sharded[K, V]) Stats() (st cache.Stats) {
+	for _, s := range c.shards {
+		st.Merge(s.Stats())
+	}
+	return st
+}
@@ -0,0 +1,142 @@
+package sharded
+
+import (
+	"fast-cache/cache"
+	"fast-cache/lru"
+	"sync"
+	"testing"
+)
+
+func newLRUShard(size int) (cache.Cache[int, int], error) {
+	return lru.New[int, int](size)
+}
+
+func TestSharded(t *testing.T) {
+	c, err := New[int, int](64, 4, newLRUShard)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 64; i++ {
+		c.Add(i, i)
+	}
+	for i := 0; i < 64; i++ {
+		if v, ok := c.Peek(i); ok && v != i {
+			t.Fatalf("bad value for %d: %d", i, v)
+		}
+	}
+	if got := c.Len(); got > 64 {
+		t.Fatalf("bad len: %d", got)
+	}
+	if got := len(c.Keys(false)); got != c.Len() {
+		t.Fatalf("bad keys len: %d", got)
+	}
+
+	evicted, err := c.Resize(8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got := c.Len(); got > 8 {
+		t.Fatalf("bad len after resize: %d, evicted %d", got, evicted)
+	}
+	if _, err := c.Resize(3); err == nil {
+		t.Fatalf("resize below the number of shards should fail")
+	}
+
+	c.Purge()
+	if got := c.Len(); got != 0 {
+		t.Fatalf("bad len after purge: %d", got)
+	}
+}
+
+func TestSharded_Params(t *testing.T) {
+	if _, err := New[int, int](2, 4, newLRUShard); err == nil {
+		t.Fatalf("size below the number of shards should fail")
+	}
+	if _, err := New[int, int](4, 0, newLRUShard); err == nil {
+		t.Fatalf("zero shards should fail")
+	}
+
+	// every key on shard 0
+	c, err := NewParams[int, int](8, 4, func(int) uint64 { return 0 }, newLRUShard)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 8; i++ {
+		c.Add(i, i)
+	}
+	if got := c.Len(); got != 2 {
+		t.Fatalf("bad len: %d", got)
+	}
+}
+
+func TestDefaultHasher(t *testing.T) {
+	type key struct {
+		name string
+		f    float64
+	}
+	zero := 0.0
+	if DefaultHasher(key{"a", zero}) != DefaultHasher(key{"a", -zero}) {
+		t.Fatalf("-0 and 0 should hash equally")
+	}
+
+	// a pointer hashes by address, not by what it points to
+	v := key{"a", 1}
+	h := DefaultHasher(&v)
+	v.f = 2
+	if DefaultHasher(&v) != h {
+		t.Fatalf("pointer hash should not change with its pointee")
+	}
+	if n := testing.AllocsPerRun(100, func() { DefaultHasher(key{"a", 1}) }); n > 1 {
+		t.Fatalf("bad allocs: %v", n)
+	}
+}
+
+func TestSharded_Concurrent(t *testing.T) {
+	c, err := New[int, int](1024, 16, newLRUShard)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				k := (i * (g + 1)) % 2048
+				switch i % 4 {
+				case 0:
+					c.Add(k, k)
+				case 1:
+					c.Get(k)
+				case 2:
+					c.Contains(k)
+				case 3:
+					c.Remove(k)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if got := c.Len(); got > 1024 {
+		t.Fatalf("bad len: %d", got)
+	}
+}
+
+func BenchmarkSharded_Parallel(b *testing.B) {
+	c, err := New[int64, int64](8192, 64, func(size int) (cache.Cache[int64, int64], error) {
+		return lru.New[int64, int64](size)
+	})
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		var i int64
+		for pb.Next() {
+			k := i % 16384
+			if _, ok := c.Get(k); !ok {
+				c.Add(k, k)
+			}
+			i++
+		}
+	})
+}
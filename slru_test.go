@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fast-cache/lru"
+	"reflect"
+	"testing"
+)
+
+func TestSLRU_Demote(t *testing.T) {
+	l, err := lru.NewSLRU[int, int](4, 0.5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 1; i <= 4; i++ {
+		l.Add(i, i)
+	}
+	l.Get(1)
+	l.Get(2)
+	// protected is full, so 1 is demoted to the front of probation
+	l.Get(3)
+	if keys := l.Keys(false); !reflect.DeepEqual(keys, []int{4, 1, 2, 3}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if !l.Add(5, 5) || l.Contains(4) {
+		t.Fatalf("4 should be evicted")
+	}
+	if keys := l.Keys(false); !reflect.DeepEqual(keys, []int{1, 5, 2, 3}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	if keys := l.Keys(true); !reflect.DeepEqual(keys, []int{3, 2, 5, 1}) {
+		t.Fatalf("bad reversed keys: %v", keys)
+	}
+
+	// shrinking evicts probation first and demotes protected overflow
+	if evicted, _ := l.Resize(2); evicted != 2 {
+		t.Fatalf("bad evicted: %d", evicted)
+	}
+	if keys := l.Keys(false); !reflect.DeepEqual(keys, []int{2, 3}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestSLRU_Invalid(t *testing.T) {
+	if _, err := lru.NewSLRU[int, int](0, 0.5); err == nil {
+		t.Fatalf("should fail on size 0")
+	}
+	if _, err := lru.NewSLRU[int, int](4, 1.5); err == nil {
+		t.Fatalf("should fail on ratio 1.5")
+	}
+}
+
+func TestSLRU_RandomOps(t *testing.T) {
+	size := 128
+	l, err := lru.NewSLRU[int64, int64](size, lru.DefaultSLRUProtectedRatio)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	n := 200000
+	for i := 0; i < n; i++ {
+		key := getRand(t) % 512
+		r := getRand(t)
+		switch r % 3 {
+		case 0:
+			l.Add(key, key)
+		case 1:
+			l.Get(key)
+		case 2:
+			l.Remove(key)
+		}
+
+		if l.Len() > size {
+			t.Fatalf("bad: len: %d", l.Len())
+		}
+	}
+}
+
+func BenchmarkSLRU_Freq(b *testing.B) {
+	l, err := lru.NewSLRU[int64, int64](8192, lru.DefaultSLRUProtectedRatio)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+
+	trace := make([]int64, b.N*2)
+	for i := 0; i < b.N*2; i++ {
+		if i%2 == 0 {
+			trace[i] = getRand(b) % 16384
+		} else {
+			trace[i] = getRand(b) % 32768
+		}
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l.Add(trace[i], trace[i])
+	}
+	var hit, miss int
+	for i := 0; i < b.N; i++ {
+		if _, ok := l.Get(trace[i]); ok {
+			hit++
+		} else {
+			miss++
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %f", hit, miss, float64(hit)/float64(hit+miss))
+}
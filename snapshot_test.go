@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/lru"
+	"reflect"
+	"testing"
+)
+
+func TestLRU_Snapshot(t *testing.T) {
+	l, err := lru.New[int, string](4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i, v := range []string{"a", "b", "c", "d"} {
+		l.Add(i, v)
+	}
+	l.Get(0)
+
+	for _, codec := range []cache.Codec[int, string]{nil, cache.JSONCodec[int, string]{}} {
+		l.SetCodec(codec)
+		var buf bytes.Buffer
+		if err := l.Snapshot(&buf); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		r, _ := lru.New[int, string](4)
+		r.SetCodec(codec)
+		r.Add(9, "z")
+		if err := r.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !reflect.DeepEqual(r.Keys(false), l.Keys(false)) || !reflect.DeepEqual(r.Values(false), l.Values(false)) {
+			t.Fatalf("bad: %v %v", r.Keys(false), r.Values(false))
+		}
+
+		// a smaller cache keeps the most recent entries
+		small, _ := lru.New[int, string](2)
+		small.SetCodec(codec)
+		if err := small.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if keys := small.Keys(false); !reflect.DeepEqual(keys, []int{3, 0}) {
+			t.Fatalf("bad: %v", keys)
+		}
+	}
+}
+
+func Test2Q_Snapshot(t *testing.T) {
+	l, err := lru.New2Q[int, int](8)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// 0 and 1 become frequent, later keys push the oldest recent ones to
+	// the ghost list
+	for i := 0; i < 12; i++ {
+		l.Add(i, i)
+		if i < 2 {
+			l.Get(i)
+		}
+	}
+	ghost := -1
+	for i := 2; i < 12; i++ {
+		if !l.Contains(i) {
+			ghost = i
+			break
+		}
+	}
+	if ghost < 0 {
+		t.Fatalf("no key was evicted")
+	}
+
+	var buf bytes.Buffer
+	if err := l.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, _ := lru.New2Q[int, int](8)
+	if err := r.Restore(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(r.Keys(false), l.Keys(false)) {
+		t.Fatalf("bad: %v, want %v", r.Keys(false), l.Keys(false))
+	}
+
+	// the ghost list survived, so the evicted key comes back frequent in
+	// both caches, and the same key is evicted next
+	r.EnableStats()
+	l.Add(ghost, ghost)
+	r.Add(ghost, ghost)
+	if r.Stats().GhostHits != 1 {
+		t.Fatalf("bad: %+v", r.Stats())
+	}
+	l.Add(100, 100)
+	r.Add(100, 100)
+	if !reflect.DeepEqual(r.Keys(false), l.Keys(false)) {
+		t.Fatalf("bad: %v, want %v", r.Keys(false), l.Keys(false))
+	}
+
+	// a snapshot only restores into the same policy
+	o, _ := lru.New[int, int](8)
+	buf.Reset()
+	if err := o.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := r.Restore(&buf); !errors.Is(err, cache.ErrInvalidSnapshot) || r.Len() != 8 {
+		t.Fatalf("should fail on an lru snapshot: %v", err)
+	}
+}
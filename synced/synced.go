@@ -0,0 +1,296 @@
+package synced
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/clock"
+	"fast-cache/fifo"
+	"fast-cache/lfu"
+	"fast-cache/lru"
+	"fast-cache/tinylfu"
+	"io"
+	"sync"
+)
+
+var _ cache.Cache[int, int] = (*Cache[int, int])(nil)
+var _ cache.StatsProvider = (*Cache[int, int])(nil)
+var _ cache.CapacityProvider = (*Cache[int, int])(nil)
+var _ cache.Snapshotter = (*Cache[int, int])(nil)
+
+// Cache is a thread-safe wrapper around any cache.Cache. Reads that do not
+// touch the policy bookkeeping (Peek, Contains, Keys, Values, Len) share a
+// read lock, everything else is serialized.
+type Cache[K comparable, V any] struct {
+	c    cache.Cache[K, V]
+	lock sync.RWMutex
+}
+
+// Wrap makes c safe for concurrent use. c must not be used directly
+// afterwards.
+func Wrap[K comparable, V any](c cache.Cache[K, V]) *Cache[K, V] {
+	return &Cache[K, V]{c: c}
+}
+
+// NewLRU constructs a thread-safe lru.LRU of the given size
+func NewLRU[K comparable, V any](size int, onEvict lru.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := lru.NewLRU[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewLFU constructs a thread-safe lfu.LFU of the given size
+func NewLFU[K comparable, V any](size int, onEvict lfu.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := lfu.NewLFU[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewBucketLFU constructs a thread-safe lfu.BucketLFU of the given size
+func NewBucketLFU[K comparable, V any](size int, onEvict lfu.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := lfu.NewBucketLFU[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewTinyLFU constructs a thread-safe tinylfu.TinyLFU of the given size
+func NewTinyLFU[K comparable, V any](size int, onEvict tinylfu.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := tinylfu.New[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewFIFO constructs a thread-safe fifo.FIFO of the given size
+func NewFIFO[K comparable, V any](size int, onEvict fifo.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := fifo.NewFIFO[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewS3FIFO constructs a thread-safe fifo.S3FIFO of the given size
+func NewS3FIFO[K comparable, V any](size int, onEvict fifo.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := fifo.NewS3FIFO[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewSieve constructs a thread-safe fifo.Sieve of the given size
+func NewSieve[K comparable, V any](size int, onEvict fifo.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := fifo.NewSieve[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewClock constructs a thread-safe clock.Clock of the given size
+func NewClock[K comparable, V any](size int, onEvict clock.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := clock.NewClock[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewClockSweep constructs a thread-safe clock.ClockSweep of the given size
+func NewClockSweep[K comparable, V any](size int, onEvict clock.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := clock.NewClockSweep[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewWSClock constructs a thread-safe clock.WSClock of the given size
+func NewWSClock[K comparable, V any](size int, onEvict clock.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := clock.NewWSClock[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// NewClockPro constructs a thread-safe clock.ClockPro of the given size
+func NewClockPro[K comparable, V any](size int, onEvict clock.EvictCallback[K, V]) (*Cache[K, V], error) {
+	c, err := clock.NewClockPro[K, V](size, onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap[K, V](c), nil
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.c.Add(key, value)
+}
+
+// Get looks up a key's value from the cache.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.c.Get(key)
+}
+
+// Contains checks if a key is in the cache, without updating the policy
+// bookkeeping of the key.
+func (c *Cache[K, V]) Contains(key K) (ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.c.Contains(key)
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the policy bookkeeping of the key.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.c.Peek(key)
+}
+
+// ContainsOrAdd checks if a key is in the cache without updating the
+// policy bookkeeping, and if not, adds the value.
+// Returns whether found and whether an eviction occurred.
+func (c *Cache[K, V]) ContainsOrAdd(key K, value V) (ok, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.c.Contains(key) {
+		return true, false
+	}
+	return false, c.c.Add(key, value)
+}
+
+// PeekOrAdd checks if a key is in the cache without updating the policy
+// bookkeeping, and if not, adds the value.
+// Returns the previous value, whether found and whether an eviction occurred.
+func (c *Cache[K, V]) PeekOrAdd(key K, value V) (previous V, ok, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if previous, ok = c.c.Peek(key); ok {
+		return previous, true, false
+	}
+	return previous, false, c.c.Add(key, value)
+}
+
+// GetOrAdd looks up a key's value, updating the policy bookkeeping, and if
+// not found, adds the value.
+// Returns the previous value, whether found and whether an eviction occurred.
+func (c *Cache[K, V]) GetOrAdd(key K, value V) (previous V, ok, evicted bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if previous, ok = c.c.Get(key); ok {
+		return previous, true, false
+	}
+	return previous, false, c.c.Add(key, value)
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *Cache[K, V]) Remove(key K) (present bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.c.Remove(key)
+}
+
+// Keys returns a slice of the keys in the cache, in the wrapped policy order.
+func (c *Cache[K, V]) Keys(reverse bool) []K {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.c.Keys(reverse)
+}
+
+// Values returns a slice of the values in the cache, in the same order as Keys.
+func (c *Cache[K, V]) Values(reverse bool) []V {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.c.Values(reverse)
+}
+
+// Len returns the number of items in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.c.Len()
+}
+
+// Cap returns the capacity of the wrapped cache, zero if it is not a
+// cache.CapacityProvider.
+func (c *Cache[K, V]) Cap() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if cp, ok := c.c.(cache.CapacityProvider); ok {
+		return cp.Cap()
+	}
+	return 0
+}
+
+// Purge is used to completely clear the cache.
+func (c *Cache[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.c.Purge()
+}
+
+// Resize changes the cache size.
+func (c *Cache[K, V]) Resize(size int) (evicted int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.c.Resize(size)
+}
+
+// EnableStats starts counting Stats if the wrapped cache is a
+// cache.StatsProvider, it must be called before the cache is shared
+// between goroutines.
+func (c *Cache[K, V]) EnableStats() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if sp, ok := c.c.(cache.StatsProvider); ok {
+		sp.EnableStats()
+	}
+}
+
+// Stats returns a snapshot of the wrapped cache counters, zero if it does
+// not count stats. The counters are atomic so no lock is taken.
+func (c *Cache[K, V]) Stats() cache.Stats {
+	if sp, ok := c.c.(cache.StatsProvider); ok {
+		return sp.Stats()
+	}
+	return cache.Stats{}
+}
+
+// errNoSnapshot is returned by Snapshot and Restore when the wrapped cache
+// is not a cache.Snapshotter.
+var errNoSnapshot = errors.New("cache does not support snapshots")
+
+// Snapshot writes the wrapped cache to w if it is a cache.Snapshotter.
+func (c *Cache[K, V]) Snapshot(w io.Writer) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if s, ok := c.c.(cache.Snapshotter); ok {
+		return s.Snapshot(w)
+	}
+	return errNoSnapshot
+}
+
+// Restore loads a snapshot into the wrapped cache if it is a
+// cache.Snapshotter.
+func (c *Cache[K, V]) Restore(r io.Reader) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if s, ok := c.c.(cache.Snapshotter); ok {
+		return s.Restore(r)
+	}
+	return errNoSnapshot
+}
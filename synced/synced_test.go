@@ -0,0 +1,105 @@
+package synced
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCompoundOps(t *testing.T) {
+	c, err := NewLRU[int, int](2, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if ok, evicted := c.ContainsOrAdd(1, 1); ok || evicted {
+		t.Fatalf("1 should not have been contained: %v, %v", ok, evicted)
+	}
+	if ok, _ := c.ContainsOrAdd(1, 10); !ok {
+		t.Fatalf("1 should have been contained")
+	}
+	if v, ok, _ := c.PeekOrAdd(1, 10); !ok || v != 1 {
+		t.Fatalf("1 should be set to 1: %v, %v", v, ok)
+	}
+	if _, ok, evicted := c.PeekOrAdd(2, 2); ok || evicted {
+		t.Fatalf("2 should not have been contained: %v, %v", ok, evicted)
+	}
+	// 1 is now the most recently used, so 2 is evicted by 3
+	if v, ok, _ := c.GetOrAdd(1, 10); !ok || v != 1 {
+		t.Fatalf("1 should be set to 1: %v, %v", v, ok)
+	}
+	if _, ok, evicted := c.GetOrAdd(3, 3); ok || !evicted {
+		t.Fatalf("3 should have evicted: %v, %v", ok, evicted)
+	}
+	if c.Contains(2) {
+		t.Fatalf("2 should have been evicted")
+	}
+}
+
+func TestConstructors(t *testing.T) {
+	for name, newCache := range map[string]func(int) (*Cache[int, int], error){
+		"lru":         func(n int) (*Cache[int, int], error) { return NewLRU[int, int](n, nil) },
+		"lfu":         func(n int) (*Cache[int, int], error) { return NewLFU[int, int](n, nil) },
+		"fifo":        func(n int) (*Cache[int, int], error) { return NewFIFO[int, int](n, nil) },
+		"clock":       func(n int) (*Cache[int, int], error) { return NewClock[int, int](n, nil) },
+		"clock-sweep": func(n int) (*Cache[int, int], error) { return NewClockSweep[int, int](n, nil) },
+		"wsclock":     func(n int) (*Cache[int, int], error) { return NewWSClock[int, int](n, nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newCache(0); err == nil {
+				t.Fatalf("size 0 should fail")
+			}
+			c, err := newCache(64)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			// every key is added exactly once no matter how many goroutines race
+			var adds atomic.Int64
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for k := 0; k < 32; k++ {
+						if ok, _ := c.ContainsOrAdd(k, k); !ok {
+							adds.Add(1)
+						}
+						c.Get(k)
+						c.Keys(false)
+					}
+				}()
+			}
+			wg.Wait()
+			if got := adds.Load(); got != 32 {
+				t.Fatalf("bad number of adds: %d", got)
+			}
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	c, err := NewLRU[int, int](4, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.Add(1, 1)
+	c.Add(2, 2)
+	var buf bytes.Buffer
+	if err := c.Snapshot(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	r, _ := NewLRU[int, int](4, nil)
+	if err := r.Restore(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, ok := r.Peek(2); !ok || v != 2 || r.Len() != 2 {
+		t.Fatalf("bad: %v", r.Keys(false))
+	}
+
+	s, _ := NewSieve[int, int](4, nil)
+	if err := s.Snapshot(&buf); err == nil {
+		t.Fatalf("should fail on a cache without snapshots")
+	}
+}
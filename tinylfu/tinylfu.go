@@ -0,0 +1,348 @@
+package tinylfu
+
+import (
+	"errors"
+	"fast-cache/cache"
+	"fast-cache/internal"
+)
+
+// EvictCallback is used to get a callback when a cache entry is evicted
+type EvictCallback[K comparable, V any] func(key K, value V)
+
+var _ cache.Cache[int, int] = (*TinyLFU[int, int])(nil)
+var _ cache.StatsProvider = (*TinyLFU[int, int])(nil)
+var _ cache.CapacityProvider = (*TinyLFU[int, int])(nil)
+
+const (
+	// DefaultWindowRatio is the share of the cache given to the window LRU.
+	DefaultWindowRatio = 0.01
+	// DefaultProtectedRatio is the share of the main region given to the
+	// protected segment.
+	DefaultProtectedRatio = 0.80
+)
+
+// segment is the region of the cache an entry lives in.
+type segment uint8
+
+const (
+	window segment = iota
+	probation
+	protected
+)
+
+// node is an entry with the segment it belongs to.
+type node[K comparable, V any] struct {
+	internal.Entry[K, V]
+	segment segment
+}
+
+// TinyLFU implements a non-thread safe fixed size W-TinyLFU cache. New
+// entries go to a small window LRU, and the window's LRU entry only enters
+// the main segmented LRU if the frequency sketch estimates it more popular
+// than the entry the main region would evict. Main entries start in the
+// probation segment and move to the protected one when referenced again.
+type TinyLFU[K comparable, V any] struct {
+	size           int
+	windowSize     int
+	protectedSize  int
+	windowRatio    float64
+	protectedRatio float64
+
+	items     map[K]*node[K, V]
+	window    internal.LruList[K, V]
+	probation internal.LruList[K, V]
+	protected internal.LruList[K, V]
+	sketch    *internal.TinyLFU
+	onEvict   EvictCallback[K, V]
+	stats     *cache.StatsCounter
+}
+
+// New constructs a TinyLFU of the given size using the default ratios.
+func New[K comparable, V any](size int, onEvict EvictCallback[K, V]) (*TinyLFU[K, V], error) {
+	return NewParams[K, V](size, DefaultWindowRatio, DefaultProtectedRatio, onEvict)
+}
+
+// NewParams constructs a TinyLFU of the given size, windowRatio is the
+// share of the cache given to the window and protectedRatio the share of
+// the main region given to the protected segment.
+func NewParams[K comparable, V any](size int, windowRatio, protectedRatio float64, onEvict EvictCallback[K, V]) (*TinyLFU[K, V], error) {
+	if size <= 0 {
+		return nil, errors.New("must provide a positive size")
+	}
+	if windowRatio <= 0.0 || windowRatio > 1.0 {
+		return nil, errors.New("invalid window ratio")
+	}
+	if protectedRatio < 0.0 || protectedRatio > 1.0 {
+		return nil, errors.New("invalid protected ratio")
+	}
+
+	c := &TinyLFU[K, V]{
+		windowRatio:    windowRatio,
+		protectedRatio: protectedRatio,
+		items:          make(map[K]*node[K, V], size),
+		sketch:         internal.NewTinyLFU(size),
+		onEvict:        onEvict,
+	}
+	c.setSize(size)
+	return c, nil
+}
+
+// setSize splits size between the window and the main region.
+func (c *TinyLFU[K, V]) setSize(size int) {
+	c.size = size
+	c.windowSize = max(1, int(float64(size)*c.windowRatio))
+	c.protectedSize = int(float64(size-c.windowSize) * c.protectedRatio)
+}
+
+// Add adds a value to the cache.  Returns true if an eviction occurred.
+func (c *TinyLFU[K, V]) Add(key K, value V) (evicted bool) {
+	c.sketch.Increment(internal.Hash(key))
+
+	// Check for existing item
+	if n, ok := c.items[key]; ok {
+		n.Value = value
+		c.touch(n)
+		c.stats.Updated()
+		return false
+	}
+
+	n := &node[K, V]{segment: window}
+	n.Key, n.Value = key, value
+	c.window.PushEntryFront(&n.Entry)
+	c.items[key] = n
+	c.stats.Added()
+
+	for c.window.Length() > c.windowSize {
+		if c.admit(c.items[c.window.Back().Key]) {
+			evicted = true
+		}
+	}
+	return evicted
+}
+
+// admit moves the window's LRU entry n into the main region, if the main
+// region is full n competes with the probation LRU entry and the less
+// frequent one is evicted. Returns true if an eviction occurred.
+func (c *TinyLFU[K, V]) admit(n *node[K, V]) (evicted bool) {
+	c.window.Remove(&n.Entry)
+	if c.probation.Length()+c.protected.Length() < c.size-c.windowSize {
+		c.pushProbation(n)
+		return false
+	}
+
+	victim := c.mainVictim()
+	if victim == nil || c.sketch.Estimate(internal.Hash(n.Key)) <= c.sketch.Estimate(internal.Hash(victim.Key)) {
+		c.evictNode(n, cache.EvictReasonCapacity)
+		return true
+	}
+	c.removeNode(victim, cache.EvictReasonCapacity)
+	c.pushProbation(n)
+	return true
+}
+
+// mainVictim returns the entry the main region evicts next, nil if empty.
+func (c *TinyLFU[K, V]) mainVictim() *node[K, V] {
+	if e := c.probation.Back(); e != nil {
+		return c.items[e.Key]
+	}
+	if e := c.protected.Back(); e != nil {
+		return c.items[e.Key]
+	}
+	return nil
+}
+
+func (c *TinyLFU[K, V]) pushProbation(n *node[K, V]) {
+	n.segment = probation
+	c.probation.PushEntryFront(&n.Entry)
+}
+
+// touch records a reference to n, promoting it from probation.
+func (c *TinyLFU[K, V]) touch(n *node[K, V]) {
+	switch n.segment {
+	case window:
+		c.window.MoveToFront(&n.Entry)
+	case protected:
+		c.protected.MoveToFront(&n.Entry)
+	case probation:
+		c.probation.Remove(&n.Entry)
+		n.segment = protected
+		c.protected.PushEntryFront(&n.Entry)
+		c.stats.Promoted()
+		c.demoteProtected()
+	}
+}
+
+// demoteProtected moves the protected LRU entries back to probation while
+// the protected segment is over its size.
+func (c *TinyLFU[K, V]) demoteProtected() {
+	for c.protected.Length() > c.protectedSize {
+		n := c.items[c.protected.Back().Key]
+		c.protected.Remove(&n.Entry)
+		c.pushProbation(n)
+	}
+}
+
+// Get looks up a key's value from the cache.
+func (c *TinyLFU[K, V]) Get(key K) (value V, ok bool) {
+	c.sketch.Increment(internal.Hash(key))
+	if n, ok := c.items[key]; ok {
+		c.touch(n)
+		c.stats.Hit()
+		return n.Value, true
+	}
+	c.stats.Miss()
+	return
+}
+
+// Peek returns the key value (or undefined if not found) without updating
+// the recency or frequency of the key.
+func (c *TinyLFU[K, V]) Peek(key K) (value V, ok bool) {
+	if n, ok := c.items[key]; ok {
+		return n.Value, true
+	}
+	return
+}
+
+// Contains checks if a key is in the cache, without updating the recency
+// or frequency of the key.
+func (c *TinyLFU[K, V]) Contains(key K) (ok bool) {
+	_, ok = c.items[key]
+	return ok
+}
+
+// Remove removes the provided key from the cache, returning if the
+// key was contained.
+func (c *TinyLFU[K, V]) Remove(key K) (present bool) {
+	if n, ok := c.items[key]; ok {
+		c.removeNode(n, cache.EvictReasonRemoved)
+		return true
+	}
+	return false
+}
+
+// list returns the list of segment s.
+func (c *TinyLFU[K, V]) list(s segment) *internal.LruList[K, V] {
+	switch s {
+	case window:
+		return &c.window
+	case probation:
+		return &c.probation
+	}
+	return &c.protected
+}
+
+// removeNode unlinks n from its segment and evicts it.
+func (c *TinyLFU[K, V]) removeNode(n *node[K, V], reason cache.EvictReason) {
+	c.list(n.segment).Remove(&n.Entry)
+	c.evictNode(n, reason)
+}
+
+// evictNode drops n, which must already be unlinked, from the cache.
+func (c *TinyLFU[K, V]) evictNode(n *node[K, V], reason cache.EvictReason) {
+	delete(c.items, n.Key)
+	c.stats.Evicted(reason, 1)
+	if c.onEvict != nil {
+		c.onEvict(n.Key, n.Value)
+	}
+}
+
+// entries returns the entries from the next to be evicted to the last:
+// probation, protected then window, each from least recently used.
+func (c *TinyLFU[K, V]) entries(reverse bool) []*internal.Entry[K, V] {
+	entries := make([]*internal.Entry[K, V], 0, len(c.items))
+	for _, l := range []*internal.LruList[K, V]{&c.probation, &c.protected, &c.window} {
+		for e := l.Back(); e != nil; e = e.PrevEntry() {
+			entries = append(entries, e)
+		}
+	}
+	if reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	return entries
+}
+
+// Keys returns a slice of the keys in the cache, from the next to be
+// evicted to the last.
+func (c *TinyLFU[K, V]) Keys(reverse bool) []K {
+	entries := c.entries(reverse)
+	keys := make([]K, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
+}
+
+// Values returns a slice of the values in the cache, in the same order as
+// Keys.
+func (c *TinyLFU[K, V]) Values(reverse bool) []V {
+	entries := c.entries(reverse)
+	values := make([]V, len(entries))
+	for i, e := range entries {
+		values[i] = e.Value
+	}
+	return values
+}
+
+// Len returns the number of items in the cache.
+func (c *TinyLFU[K, V]) Len() int {
+	return len(c.items)
+}
+
+// Cap returns the size of the cache.
+func (c *TinyLFU[K, V]) Cap() int {
+	return c.size
+}
+
+// Purge is used to completely clear the cache and its frequency sketch.
+func (c *TinyLFU[K, V]) Purge() {
+	c.stats.Evicted(cache.EvictReasonPurged, len(c.items))
+	for k, n := range c.items {
+		if c.onEvict != nil {
+			c.onEvict(k, n.Value)
+		}
+		delete(c.items, k)
+	}
+	c.window.Init()
+	c.probation.Init()
+	c.protected.Init()
+	c.sketch.Reset()
+}
+
+// Resize changes the cache size, evicting from the main region first.
+func (c *TinyLFU[K, V]) Resize(size int) (evicted int, err error) {
+	if size <= 0 {
+		return c.Len() - size, errors.New("must provide a positive size")
+	}
+	c.setSize(size)
+	for len(c.items) > size {
+		if n := c.mainVictim(); n != nil {
+			c.removeNode(n, cache.EvictReasonCapacity)
+		} else {
+			c.removeNode(c.items[c.window.Back().Key], cache.EvictReasonCapacity)
+		}
+		evicted++
+	}
+	// spill window entries over the new window size into the main region
+	for c.window.Length() > c.windowSize {
+		n := c.items[c.window.Back().Key]
+		c.window.Remove(&n.Entry)
+		c.pushProbation(n)
+	}
+	c.demoteProtected()
+	return evicted, nil
+}
+
+// EnableStats starts counting Stats, it must be called before the cache is
+// shared between goroutines.
+func (c *TinyLFU[K, V]) EnableStats() {
+	if c.stats == nil {
+		c.stats = new(cache.StatsCounter)
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *TinyLFU[K, V]) Stats() cache.Stats {
+	return c.stats.Snapshot()
+}
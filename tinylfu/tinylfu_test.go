@@ -0,0 +1,111 @@
+package tinylfu
+
+import (
+	"fast-cache/lru"
+	"math/rand"
+	"testing"
+)
+
+func TestTinyLFU(t *testing.T) {
+	var evicted []int
+	c, err := NewParams[int, int](4, 0.25, 0.5, func(k, _ int) {
+		evicted = append(evicted, k)
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		c.Add(i, i)
+	}
+	if c.Len() != 4 {
+		t.Fatalf("Len() = %d", c.Len())
+	}
+	// 1 and 2 become frequent, 2 is promoted to protected
+	for i := 0; i < 3; i++ {
+		c.Get(1)
+		c.Get(2)
+	}
+
+	// 4 pushes 3 out of the window, 3 is no more frequent than the
+	// probation LRU entry 0 so it is not admitted
+	if !c.Add(4, 4) || !c.Contains(4) || c.Contains(3) || !c.Contains(0) {
+		t.Fatalf("3 should not be admitted: %v", c.Keys(false))
+	}
+	if !c.Add(5, 5) || c.Contains(4) {
+		t.Fatalf("4 should not be admitted: %v", c.Keys(false))
+	}
+	if !c.Contains(1) || !c.Contains(2) {
+		t.Fatalf("frequent keys evicted: %v", c.Keys(false))
+	}
+	if v, ok := c.Peek(5); !ok || v != 5 {
+		t.Fatalf("Peek(5) = %v, %v", v, ok)
+	}
+
+	if !c.Remove(5) || c.Remove(5) {
+		t.Fatalf("bad Remove")
+	}
+	if n, err := c.Resize(2); err != nil || n != 1 || c.Len() != 2 {
+		t.Fatalf("Resize(2) = %d, %v: %v", n, err, c.Keys(false))
+	}
+	c.Purge()
+	if c.Len() != 0 || len(c.Keys(true)) != 0 {
+		t.Fatalf("bad Purge: %v", c.Keys(false))
+	}
+	if len(evicted) != 6 {
+		t.Fatalf("evicted %v", evicted)
+	}
+}
+
+func TestTinyLFU_Zipf(t *testing.T) {
+	const size, n = 100, 200000
+	c, err := New[uint64, uint64](size, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	l, err := lru.New[uint64, uint64](size)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c.EnableStats()
+	l.EnableStats()
+
+	z := rand.NewZipf(rand.New(rand.NewSource(1)), 1.01, 1, 10000)
+	for i := 0; i < n; i++ {
+		k := z.Uint64()
+		if _, ok := c.Get(k); !ok {
+			c.Add(k, k)
+		}
+		if _, ok := l.Get(k); !ok {
+			l.Add(k, k)
+		}
+	}
+	tiny, plain := c.Stats().HitRatio(), l.Stats().HitRatio()
+	if tiny <= plain {
+		t.Fatalf("TinyLFU hit ratio %.3f <= LRU %.3f", tiny, plain)
+	}
+	if c.Len() > size {
+		t.Fatalf("Len() = %d", c.Len())
+	}
+}
+
+func BenchmarkTinyLFU_Rand(b *testing.B) {
+	c, err := New[int, int](8192, nil)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	trace := make([]int, b.N)
+	for i := range trace {
+		trace[i] = rand.Intn(32768)
+	}
+	b.ResetTimer()
+	var hit, miss int
+	for _, k := range trace {
+		if _, ok := c.Get(k); ok {
+			hit++
+		} else {
+			c.Add(k, k)
+			miss++
+		}
+	}
+	b.Logf("hit: %d miss: %d ratio: %.3f", hit, miss, float64(hit)/float64(hit+miss))
+}
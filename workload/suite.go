@@ -0,0 +1,44 @@
+package workload
+
+import "fast-cache/cache"
+
+// Spec names a generator of a benchmark suite, New returns a fresh stream.
+type Spec struct {
+	Name string
+	New  func() Generator
+}
+
+// Suite returns the workloads the policy benchmarks run, over about n keys.
+// Streams of the same seed are identical.
+func Suite(seed int64, n uint64) []Spec {
+	n = max(n, 10)
+	return []Spec{
+		{"zipf", func() Generator { return must(NewZipf(seed, 0.99, n)) }},
+		{"scan-mix", func() Generator { return must(NewScanMix(seed, n/10, n, 0.5)) }},
+		{"loop", func() Generator { return must(NewLoop(n / 4)) }},
+		{"shifting", func() Generator { return must(NewShifting(seed, n, n/10, 0.9, int(n))) }},
+		{"two-pool", func() Generator { return must(NewTwoPool(seed, n/100+1, n)) }},
+		{"hot-cold", func() Generator { return must(NewHotCold(seed, n, 0.8, 0.2)) }},
+	}
+}
+
+func must[G Generator](g G, err error) Generator {
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Replay runs keys through c, adding every missed key with the zero value,
+// and returns the number of hits.
+func Replay[V any](c cache.Cache[uint64, V], keys []uint64) (hits int) {
+	var zero V
+	for _, k := range keys {
+		if _, ok := c.Get(k); ok {
+			hits++
+			continue
+		}
+		c.Add(k, zero)
+	}
+	return hits
+}
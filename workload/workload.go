@@ -0,0 +1,233 @@
+// Package workload generates deterministic synthetic key streams to
+// benchmark and simulate cache policies. Every generator is seeded, so the
+// same parameters always produce the same stream.
+package workload
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+var (
+	_ Generator = (*Uniform)(nil)
+	_ Generator = (*Zipf)(nil)
+	_ Generator = (*Loop)(nil)
+	_ Generator = (*ScanMix)(nil)
+	_ Generator = (*Shifting)(nil)
+	_ Generator = (*TwoPool)(nil)
+	_ Generator = (*HotCold)(nil)
+)
+
+// Generator produces a stream of keys.
+type Generator interface {
+	// Next returns the next key of the stream.
+	Next() uint64
+}
+
+// Keys returns the next n keys of g.
+func Keys(g Generator, n int) []uint64 {
+	keys := make([]uint64, n)
+	for i := range keys {
+		keys[i] = g.Next()
+	}
+	return keys
+}
+
+// Uniform draws keys in [0, n) with equal probability.
+type Uniform struct {
+	rng *rand.Rand
+	n   uint64
+}
+
+// NewUniform constructs a Uniform over n keys.
+func NewUniform(seed int64, n uint64) (*Uniform, error) {
+	if n == 0 {
+		return nil, errors.New("must provide a positive number of keys")
+	}
+	return &Uniform{rng: rand.New(rand.NewSource(seed)), n: n}, nil
+}
+
+func (g *Uniform) Next() uint64 {
+	return uint64(g.rng.Int63n(int64(g.n)))
+}
+
+// Zipf draws keys in [0, n) with the probability of key i proportional to
+// 1/(i+1)^skew, so key 0 is the most popular. A skew of zero is uniform,
+// and the larger the skew the smaller the hot set.
+type Zipf struct {
+	rng *rand.Rand
+	cdf []float64
+}
+
+// NewZipf constructs a Zipf over n keys.
+func NewZipf(seed int64, skew float64, n uint64) (*Zipf, error) {
+	if n == 0 {
+		return nil, errors.New("must provide a positive number of keys")
+	}
+	if skew < 0 || math.IsNaN(skew) || math.IsInf(skew, 0) {
+		return nil, errors.New("invalid skew")
+	}
+	cdf := make([]float64, n)
+	var sum float64
+	for i := range cdf {
+		sum += math.Pow(float64(i+1), -skew)
+		cdf[i] = sum
+	}
+	for i := range cdf {
+		cdf[i] /= sum
+	}
+	return &Zipf{rng: rand.New(rand.NewSource(seed)), cdf: cdf}, nil
+}
+
+func (g *Zipf) Next() uint64 {
+	i := sort.SearchFloat64s(g.cdf, g.rng.Float64())
+	// guard against rounding of the last cumulative probability
+	return uint64(min(i, len(g.cdf)-1))
+}
+
+// Loop cycles over the keys [0, n) in order, the worst case of LRU when n
+// exceeds the cache size.
+type Loop struct {
+	n, next uint64
+}
+
+// NewLoop constructs a Loop over n keys.
+func NewLoop(n uint64) (*Loop, error) {
+	if n == 0 {
+		return nil, errors.New("must provide a positive number of keys")
+	}
+	return &Loop{n: n}, nil
+}
+
+func (g *Loop) Next() uint64 {
+	k := g.next
+	g.next = (g.next + 1) % g.n
+	return k
+}
+
+// ScanMix mixes a hot set with a sequential scan: with probability
+// hotRatio a key is drawn uniformly from the hot keys [0, hot), otherwise
+// the scan moves on to the next of the keys [hot, hot+scan), wrapping
+// around.
+type ScanMix struct {
+	rng       *rand.Rand
+	hot, scan uint64
+	hotRatio  float64
+	next      uint64
+}
+
+// NewScanMix constructs a ScanMix of hot keys and scan keys.
+func NewScanMix(seed int64, hot, scan uint64, hotRatio float64) (*ScanMix, error) {
+	if hot == 0 || scan == 0 {
+		return nil, errors.New("must provide positive numbers of keys")
+	}
+	if hotRatio < 0 || hotRatio > 1 {
+		return nil, errors.New("invalid hot ratio")
+	}
+	return &ScanMix{rng: rand.New(rand.NewSource(seed)), hot: hot, scan: scan, hotRatio: hotRatio}, nil
+}
+
+func (g *ScanMix) Next() uint64 {
+	if g.rng.Float64() < g.hotRatio {
+		return uint64(g.rng.Int63n(int64(g.hot)))
+	}
+	k := g.hot + g.next
+	g.next = (g.next + 1) % g.scan
+	return k
+}
+
+// Shifting draws keys in [0, n), with probability hotRatio from a hot set
+// of hot consecutive keys and uniformly otherwise. The hot set moves to the
+// next hot keys every period keys, so a policy must forget the old one.
+type Shifting struct {
+	rng      *rand.Rand
+	n, hot   uint64
+	hotRatio float64
+	period   int
+	count    int
+	offset   uint64
+}
+
+// NewShifting constructs a Shifting over n keys.
+func NewShifting(seed int64, n, hot uint64, hotRatio float64, period int) (*Shifting, error) {
+	if n == 0 || hot == 0 || hot > n {
+		return nil, errors.New("invalid number of keys")
+	}
+	if hotRatio < 0 || hotRatio > 1 {
+		return nil, errors.New("invalid hot ratio")
+	}
+	if period <= 0 {
+		return nil, errors.New("must provide a positive period")
+	}
+	return &Shifting{rng: rand.New(rand.NewSource(seed)), n: n, hot: hot, hotRatio: hotRatio, period: period}, nil
+}
+
+func (g *Shifting) Next() uint64 {
+	if g.count == g.period {
+		g.count = 0
+		g.offset = (g.offset + g.hot) % g.n
+	}
+	g.count++
+	if g.rng.Float64() < g.hotRatio {
+		return (g.offset + uint64(g.rng.Int63n(int64(g.hot)))) % g.n
+	}
+	return uint64(g.rng.Int63n(int64(g.n)))
+}
+
+// TwoPool is the two pool experiment of the LRU-K and 2Q papers: accesses
+// alternate between a small pool of keys [0, n1) and a large pool of keys
+// [n1, n1+n2), each drawn uniformly. An optimal policy keeps the small
+// pool, whose keys are referenced far more often.
+type TwoPool struct {
+	rng    *rand.Rand
+	n1, n2 uint64
+	second bool
+}
+
+// NewTwoPool constructs a TwoPool of n1 and n2 keys.
+func NewTwoPool(seed int64, n1, n2 uint64) (*TwoPool, error) {
+	if n1 == 0 || n2 == 0 {
+		return nil, errors.New("must provide positive numbers of keys")
+	}
+	return &TwoPool{rng: rand.New(rand.NewSource(seed)), n1: n1, n2: n2}, nil
+}
+
+func (g *TwoPool) Next() uint64 {
+	g.second = !g.second
+	if g.second {
+		return uint64(g.rng.Int63n(int64(g.n1)))
+	}
+	return g.n1 + uint64(g.rng.Int63n(int64(g.n2)))
+}
+
+// HotCold is the skewed workload of the LRU-K and 2Q papers: a fraction a
+// of the accesses go to a fraction b of the n keys, e.g. 80% of them to 20%
+// of the keys, each part drawn uniformly.
+type HotCold struct {
+	rng *rand.Rand
+	n   uint64
+	hot uint64
+	a   float64
+}
+
+// NewHotCold constructs a HotCold over n keys where a fraction a of the
+// accesses go to a fraction b of the keys.
+func NewHotCold(seed int64, n uint64, a, b float64) (*HotCold, error) {
+	if a < 0 || a > 1 || b <= 0 || b >= 1 {
+		return nil, errors.New("invalid fractions")
+	}
+	hot := uint64(b * float64(n))
+	if hot == 0 || hot >= n {
+		return nil, errors.New("invalid number of keys")
+	}
+	return &HotCold{rng: rand.New(rand.NewSource(seed)), n: n, hot: hot, a: a}, nil
+}
+
+func (g *HotCold) Next() uint64 {
+	if g.rng.Float64() < g.a {
+		return uint64(g.rng.Int63n(int64(g.hot)))
+	}
+	return g.hot + uint64(g.rng.Int63n(int64(g.n-g.hot)))
+}
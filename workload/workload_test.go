@@ -0,0 +1,115 @@
+package workload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeterministic(t *testing.T) {
+	gens := map[string]func() (Generator, error){
+		"uniform":  func() (Generator, error) { return NewUniform(7, 100) },
+		"zipf":     func() (Generator, error) { return NewZipf(7, 0.99, 100) },
+		"scan-mix": func() (Generator, error) { return NewScanMix(7, 10, 100, 0.5) },
+		"shifting": func() (Generator, error) { return NewShifting(7, 100, 10, 0.9, 50) },
+		"two-pool": func() (Generator, error) { return NewTwoPool(7, 10, 100) },
+		"hot-cold": func() (Generator, error) { return NewHotCold(7, 100, 0.8, 0.2) },
+	}
+	for name, newGen := range gens {
+		t.Run(name, func(t *testing.T) {
+			a, err := newGen()
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			b, _ := newGen()
+			if ka, kb := Keys(a, 1000), Keys(b, 1000); !reflect.DeepEqual(ka, kb) {
+				t.Fatalf("same seed should give the same keys")
+			}
+		})
+	}
+}
+
+func TestZipf(t *testing.T) {
+	g, err := NewZipf(1, 1.0, 1000)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	counts := make([]int, 1000)
+	for _, k := range Keys(g, 100000) {
+		counts[k]++
+	}
+	// with a skew of 1 key 0 is twice as popular as key 1, and about 13%
+	// of the accesses go to it
+	if counts[0] < 12000 || counts[0] > 14000 || counts[1] < counts[0]/3 || counts[1] > 2*counts[0]/3 {
+		t.Fatalf("bad counts: %v", counts[:4])
+	}
+	if _, err := NewZipf(1, -1, 10); err == nil {
+		t.Fatalf("should fail on a negative skew")
+	}
+}
+
+func TestLoop(t *testing.T) {
+	g, _ := NewLoop(3)
+	if keys := Keys(g, 7); !reflect.DeepEqual(keys, []uint64{0, 1, 2, 0, 1, 2, 0}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+}
+
+func TestScanMix(t *testing.T) {
+	g, _ := NewScanMix(1, 10, 5, 0)
+	if keys := Keys(g, 6); !reflect.DeepEqual(keys, []uint64{10, 11, 12, 13, 14, 10}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	g, _ = NewScanMix(1, 10, 5, 1)
+	for _, k := range Keys(g, 100) {
+		if k >= 10 {
+			t.Fatalf("key %d out of the hot set", k)
+		}
+	}
+}
+
+func TestShifting(t *testing.T) {
+	g, _ := NewShifting(1, 100, 10, 1, 20)
+	for i, k := range Keys(g, 100) {
+		lo := uint64(i/20) * 10
+		if k < lo || k >= lo+10 {
+			t.Fatalf("access %d: key %d out of the hot set at %d", i, k, lo)
+		}
+	}
+}
+
+func TestTwoPool(t *testing.T) {
+	g, _ := NewTwoPool(1, 10, 100)
+	for i, k := range Keys(g, 100) {
+		if small := k < 10; small != (i%2 == 0) {
+			t.Fatalf("access %d: key %d from the wrong pool", i, k)
+		}
+	}
+}
+
+func TestHotCold(t *testing.T) {
+	g, err := NewHotCold(1, 1000, 0.8, 0.2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var hot int
+	for _, k := range Keys(g, 10000) {
+		if k < 200 {
+			hot++
+		}
+	}
+	if hot < 7800 || hot > 8200 {
+		t.Fatalf("bad hot accesses: %d", hot)
+	}
+	if _, err := NewHotCold(1, 3, 0.8, 0.2); err == nil {
+		t.Fatalf("should fail without hot keys")
+	}
+}
+
+func TestSuite(t *testing.T) {
+	for _, spec := range Suite(1, 1000) {
+		a, b := Keys(spec.New(), 100), Keys(spec.New(), 100)
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("%s: streams should be identical", spec.Name)
+		}
+	}
+}
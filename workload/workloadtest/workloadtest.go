@@ -0,0 +1,26 @@
+// Package workloadtest benchmarks cache policies on the workload suite.
+package workloadtest
+
+import (
+	"fast-cache/cache"
+	"fast-cache/workload"
+	"testing"
+)
+
+// Benchmark replays every workload of the suite through a cache of 1024
+// entries built by newCache, one sub-benchmark per workload, reporting the
+// hit ratio.
+func Benchmark(b *testing.B, newCache func(size int) (cache.Cache[uint64, uint64], error)) {
+	for _, spec := range workload.Suite(1, 1<<14) {
+		b.Run(spec.Name, func(b *testing.B) {
+			c, err := newCache(1 << 10)
+			if err != nil {
+				b.Fatalf("err: %v", err)
+			}
+			keys := workload.Keys(spec.New(), b.N)
+			b.ResetTimer()
+			hits := workload.Replay(c, keys)
+			b.ReportMetric(float64(hits)/float64(b.N), "hit-ratio")
+		})
+	}
+}
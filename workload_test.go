@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fast-cache/cache"
+	"fast-cache/lru"
+	"fast-cache/workload/workloadtest"
+	"testing"
+)
+
+func BenchmarkLRU_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return lru.New[uint64, uint64](size)
+	})
+}
+
+func Benchmark2Q_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return lru.New2Q[uint64, uint64](size)
+	})
+}
+
+func BenchmarkARC_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return lru.NewARC[uint64, uint64](size)
+	})
+}
+
+func BenchmarkLRUK_Workloads(b *testing.B) {
+	workloadtest.Benchmark(b, func(size int) (cache.Cache[uint64, uint64], error) {
+		return lru.NewLruK[uint64, uint64](size, 2)
+	})
+}